@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// commasHandler shows or sets whether a single-line message is split into items on commas
+func (a *app) commasHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.Message == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	words := strings.Fields(update.Message.Text)
+	if words[0] != "/commas" {
+		sendMessage(ctx, b, userID, tr(ctx, ErrUnknownCommand))
+		return
+	}
+
+	var split bool
+	if len(words) < 2 {
+		settings, err := a.store.GetSettings(ctx, userID)
+		if err != nil {
+			errorLog.Printf("Failed to get settings for user %d: %v", userID, err)
+			sendMessage(ctx, b, userID, tr(ctx, ErrCommasArgs))
+			return
+		}
+		split = settings.SplitCommas
+	} else {
+		switch strings.ToLower(words[1]) {
+		case "on", "вкл":
+			split = true
+		case "off", "выкл":
+			split = false
+		default:
+			sendMessage(ctx, b, userID, tr(ctx, ErrCommasArgs))
+			return
+		}
+
+		if err := a.store.SetSplitCommas(ctx, userID, split); err != nil {
+			errorLog.Printf("Failed to set comma splitting for user %d: %v", userID, err)
+			sendMessage(ctx, b, userID, tr(ctx, ErrSaveSettings))
+			return
+		}
+	}
+
+	if split {
+		sendMessage(ctx, b, userID, tr(ctx, MsgCommasOn))
+	} else {
+		sendMessage(ctx, b, userID, tr(ctx, MsgCommasOff))
+	}
+}
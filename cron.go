@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a parsed five field cron rule: minute, hour, day of month, month, day of week
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// As in cron, when both days are restricted a time matching either of them is due
+	domAny, dowAny bool
+}
+
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// parseCron parses a rule such as "0 9 * * 6". Fields take "*", numbers, ranges "1-5",
+// lists "1,3" and steps "*/15". Sunday is 0 or 7.
+func parseCron(rule string) (cronSchedule, error) {
+	fields := strings.Fields(rule)
+	if len(fields) != len(cronFields) {
+		return cronSchedule{}, fmt.Errorf("cron rule %q must have %d fields", rule, len(cronFields))
+	}
+
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return cronSchedule{}, fmt.Errorf("invalid %s in cron rule %q: %w", cronFields[i].name, rule, err)
+		}
+		sets[i] = set
+	}
+
+	// Sunday may be written as 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+
+	return cronSchedule{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, min int, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if before, after, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(after)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", after)
+			}
+			rng, step = before, n
+		}
+
+		lo, hi := min, max
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value %q", from)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid value %q", to)
+				}
+			} else if step > 1 {
+				// "5/15" means from 5 on
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// matchesDay reports whether the rule is due on the day of t
+func (c cronSchedule) matchesDay(t time.Time) bool {
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<int(t.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// Next returns the first minute after t the rule is due, or the zero time
+// if it never is, like "0 0 31 2 *"
+func (c cronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// weekdayNames are the day names in English and Russian, full or abbreviated, in the
+// forms people write after "every", "on", "в" or "по", so "sat", "Saturday",
+// "сб" and "субботу" are all Saturday. Only whole words count, "@monitor" is no Monday.
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday, "sundays": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday, "mondays": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday, "tuesdays": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday, "wednesdays": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday, "thursdays": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday, "fridays": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday, "saturdays": time.Saturday,
+
+	"вс": time.Sunday, "воскресенье": time.Sunday, "воскресенья": time.Sunday, "воскресеньям": time.Sunday,
+	"пн": time.Monday, "понедельник": time.Monday, "понедельника": time.Monday, "понедельникам": time.Monday,
+	"вт": time.Tuesday, "вторник": time.Tuesday, "вторника": time.Tuesday, "вторникам": time.Tuesday,
+	"ср": time.Wednesday, "среда": time.Wednesday, "среду": time.Wednesday, "среды": time.Wednesday, "средам": time.Wednesday,
+	"чт": time.Thursday, "четверг": time.Thursday, "четверга": time.Thursday, "четвергам": time.Thursday,
+	"пт": time.Friday, "пятница": time.Friday, "пятницу": time.Friday, "пятницы": time.Friday, "пятницам": time.Friday,
+	"сб": time.Saturday, "суббота": time.Saturday, "субботу": time.Saturday, "субботы": time.Saturday, "субботам": time.Saturday,
+}
+
+// scheduleFillers are words people put in front of days, "every Saturday", "каждую субботу"
+var scheduleFillers = map[string]bool{
+	"every": true, "on": true, "at": true, "в": true, "во": true,
+	"каждый": true, "каждую": true, "каждое": true, "по": true,
+}
+
+// parseScheduleRule turns a rule written by a user into a cron rule. Besides cron rules
+// it understands days followed by a time: "sat 09:00", "every Saturday 9:00",
+// "mon,thu 20:00", "daily 08:00" or "каждую субботу 9:00".
+func parseScheduleRule(text string) (string, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if _, err := parseCron(text); err == nil {
+		return strings.Join(strings.Fields(text), " "), nil
+	}
+
+	words := strings.FieldsFunc(text, func(r rune) bool { return r == ' ' || r == ',' })
+	if len(words) == 0 {
+		return "", fmt.Errorf("empty schedule rule")
+	}
+
+	clock, err := time.Parse("15:04", words[len(words)-1])
+	if err != nil {
+		return "", fmt.Errorf("schedule rule %q must end with a time such as 09:00", text)
+	}
+
+	var days []string
+	seen := make(map[time.Weekday]bool)
+	for _, word := range words[:len(words)-1] {
+		if scheduleFillers[word] {
+			continue
+		}
+		if word == "daily" || word == "day" || word == "ежедневно" || word == "день" {
+			seen = nil
+			break
+		}
+		day, ok := parseWeekday(word)
+		if !ok {
+			return "", fmt.Errorf("unknown day %q in schedule rule %q", word, text)
+		}
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, strconv.Itoa(int(day)))
+		}
+	}
+
+	dow := "*"
+	if len(seen) > 0 {
+		dow = strings.Join(days, ",")
+	}
+	return fmt.Sprintf("%d %d * * %s", clock.Minute(), clock.Hour(), dow), nil
+}
+
+// parseWeekday returns the day a lower case word from weekdayNames names, "сб." included
+func parseWeekday(word string) (time.Weekday, bool) {
+	day, ok := weekdayNames[strings.TrimSuffix(word, ".")]
+	return day, ok
+}
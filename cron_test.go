@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	for _, rule := range []string{"* * * * *", "0 9 * * 6", "*/15 8-20 1,15 * 1-5", "5/15 * * * *", "0 0 * * 7"} {
+		if _, err := parseCron(rule); err != nil {
+			t.Errorf("parseCron(%q): %v", rule, err)
+		}
+	}
+	for _, rule := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := parseCron(rule); err == nil {
+			t.Errorf("parseCron(%q) accepted an invalid rule", rule)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// Friday
+	now := time.Date(2026, 10, 16, 12, 30, 20, 0, time.UTC)
+
+	tests := []struct {
+		rule string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 10, 16, 12, 31, 0, 0, time.UTC)},
+		{"0 9 * * 6", time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)},
+		{"30 12 * * 5", time.Date(2026, 10, 23, 12, 30, 0, 0, time.UTC)},
+		{"0 8 * * 0", time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)},
+		{"0 8 * * 7", time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 16, 12, 45, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Either day matches when both are restricted: the 20th or a Monday
+		{"0 7 20 * 1", time.Date(2026, 10, 19, 7, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		cron, err := parseCron(tt.rule)
+		if err != nil {
+			t.Fatalf("parseCron(%q): %v", tt.rule, err)
+		}
+		if got := cron.Next(now); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %v, want %v", tt.rule, got, tt.want)
+		}
+	}
+
+	if _, err := nextScheduleRun("0 0 31 2 *", now); err == nil {
+		t.Error("nextScheduleRun accepted a rule that is never due")
+	}
+}
+
+func TestParseScheduleRule(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"0 9 * * 6", "0 9 * * 6"},
+		{" 0  9 * *   7 ", "0 9 * * 7"},
+		{"sat 09:00", "0 9 * * 6"},
+		{"every Saturday 9:00", "0 9 * * 6"},
+		{"mon,thu 20:00", "0 20 * * 1,4"},
+		{"sun 7:30", "30 7 * * 0"},
+		{"daily 08:00", "0 8 * * *"},
+		{"каждую субботу 9:00", "0 9 * * 6"},
+		{"пн, чт 20:15", "15 20 * * 1,4"},
+		{"по воскресеньям 10:00", "0 10 * * 0"},
+	}
+	for _, tt := range tests {
+		got, err := parseScheduleRule(tt.text)
+		if err != nil || got != tt.want {
+			t.Errorf("parseScheduleRule(%q) = %q, %v, want %q", tt.text, got, err, tt.want)
+		}
+	}
+
+	for _, text := range []string{"", "sat", "saturday 25:00", "someday 09:00", "monitor 09:00", "saturn 09:00"} {
+		if got, err := parseScheduleRule(text); err == nil {
+			t.Errorf("parseScheduleRule(%q) = %q, want an error", text, got)
+		}
+	}
+}
@@ -5,71 +5,242 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type List struct {
 	gorm.Model
-	ID   int64  `gorm:"primaryKey"`
-	Name string `gorm:"not null"`
+	ID        int64  `gorm:"primaryKey"`
+	Name      string `gorm:"not null"`
+	TrashDays int    `gorm:"not null;default:0"` // 0 keeps deleted items for the janitor's default time
 }
 
 type Settings struct {
 	ID           int64
 	UserID       int64 `gorm:"primaryKey"`
 	SelectedList int64
-	List         List `gorm:"foreignKey:SelectedList"`
+	List         List   `gorm:"foreignKey:SelectedList"`
+	Notify       bool   `gorm:"not null;default:false"`
+	SplitCommas  bool   `gorm:"not null;default:false"`
+	Locale       string `gorm:"not null;default:''"` // chosen with /lang, empty to follow LanguageCode
+	LanguageCode string `gorm:"not null;default:''"` // the language of the user's Telegram
+}
+
+// Role is what a user may do with a list they have access to
+type Role string
+
+const (
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+	RoleOwner  Role = "owner"
+)
+
+var roleRanks = map[Role]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+// allows reports whether the role grants everything the required role does
+func (r Role) allows(required Role) bool {
+	return roleRanks[r] >= roleRanks[required]
+}
+
+// parseRole accepts role names in English and Russian
+func parseRole(s string) (Role, bool) {
+	switch strings.ToLower(s) {
+	case "viewer", "читатель":
+		return RoleViewer, true
+	case "editor", "редактор":
+		return RoleEditor, true
+	case "owner", "владелец":
+		return RoleOwner, true
+	}
+	return "", false
 }
 
 type ListOwners struct {
 	gorm.Model
-	UserID int64 `gorm:"index"`
-	ListID int64
-	List   List `gorm:"foreignKey:ListID"`
+	UserID int64 `gorm:"index;uniqueIndex:idx_list_owners_user_list"`
+	ListID int64 `gorm:"uniqueIndex:idx_list_owners_user_list"`
+	List   List  `gorm:"foreignKey:ListID"`
+	Role   Role  `gorm:"not null;default:owner"`
+	Muted  bool  `gorm:"not null;default:false"`
 }
 
 type ListItem struct {
 	gorm.Model
-	ID         int64  `gorm:"primaryKey" json:"id"`
-	UserID     int64  `gorm:"index" json:"user_id"`
-	Name       string `gorm:"not null" json:"name"`
-	ListID     int64  `json:"list_id"`
-	List       List   `gorm:"foreignKey:ListID" json:"list"`
-	Item_order int    `gorm:"default:0" json:"item_order"`
+	ID         int64      `gorm:"primaryKey" json:"id"`
+	UserID     int64      `gorm:"index" json:"user_id"`
+	Name       string     `gorm:"not null" json:"name"`
+	ListID     int64      `json:"list_id"`
+	List       List       `gorm:"foreignKey:ListID" json:"list"`
+	Item_order int        `gorm:"default:0" json:"item_order"`
+	Done       bool       `gorm:"not null;default:false" json:"done"`
+	Quantity   float64    `gorm:"not null;default:0" json:"quantity"` // 0 when the item has no quantity
+	Unit       string     `gorm:"not null;default:''" json:"unit"`
+	DeletedBy  int64      `gorm:"not null;default:0" json:"deleted_by,omitempty"` // 0 for items deleted before this was recorded
+	DueAt      *time.Time `gorm:"index" json:"due_at,omitempty"`
+	Reminded   bool       `gorm:"not null;default:false" json:"-"` // the members were reminded the item is due
 }
 
-func initDb() error {
-	db, err := getDb()
-	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+// Label is the item as shown to users, with its quantity: "eggs ×3" or "milk 2 l"
+func (i ListItem) Label() string {
+	switch {
+	case i.Quantity == 0:
+		return i.Name
+	case i.Unit == "":
+		return i.Name + " ×" + formatQuantity(i.Quantity)
+	default:
+		return i.Name + " " + formatQuantity(i.Quantity) + " " + i.Unit
 	}
+}
 
-	// Migrate the schema
-	if err := db.AutoMigrate(&List{}, &ListItem{}, &Settings{}, &ListOwners{}); err != nil {
-		return fmt.Errorf("failed to migrate database: %w", err)
+// count is the quantity used for merging, an item without one counts as a single piece
+func (i ListItem) count() float64 {
+	if i.Quantity == 0 {
+		return 1
 	}
+	return i.Quantity
+}
 
-	return nil
+// ErrNothingToRestore is returned when a list has no deleted items to bring back
+var ErrNothingToRestore = errors.New("no deleted items to restore")
+
+// ErrForbidden is returned when the user's role on a list doesn't allow the action
+var ErrForbidden = errors.New("not allowed by list role")
+
+// ErrItemNotFound is returned when the item is gone from a list the user has access to,
+// usually because someone else deleted it while the user's keyboard still showed it
+var ErrItemNotFound = errors.New("item not found")
+
+// Repository is the storage used by the bot and the web app handlers
+type Repository interface {
+	ListRepository
+	ItemRepository
+	OwnerRepository
+	SettingsRepository
+	InviteRepository
+	ListMessageRepository
+	JanitorRepository
+	TemplateRepository
+	ScheduleRepository
+	DueRepository
+	ImportRepository
+}
+
+// ListRepository manages lists
+type ListRepository interface {
+	CreateList(ctx context.Context, userID int64, listName string) (List, error)
+	GetList(ctx context.Context, listID int64) (List, error)
+	UserLists(ctx context.Context, userID int64) ([]List, error)
+	SelectListByID(ctx context.Context, userID int64, listID int64) error
+	SelectListByName(ctx context.Context, userID int64, listName string) error
+	RenameList(ctx context.Context, userID int64, listID int64, listName string) error
+	DeleteList(ctx context.Context, userID int64, listID int64) error
+	DeletedLists(ctx context.Context, userID int64) ([]List, error)
+	RestoreList(ctx context.Context, userID int64, listID int64) (List, error)
+	SetTrashDays(ctx context.Context, userID int64, listID int64, days int) error
+	CloneList(ctx context.Context, userID int64, listID int64, name string) (List, error)
+	ImportList(ctx context.Context, userID int64, name string, items []ListItem) (List, error)
+}
+
+// ItemRepository manages list items
+type ItemRepository interface {
+	AddItem(ctx context.Context, userID int64, listID int64, itemName string) (ListItem, error)
+	AddItems(ctx context.Context, userID int64, listID int64, newItems []ListItem) ([]ListItem, error)
+	ListItems(ctx context.Context, listID int64) ([]ListItem, error)
+	DeleteListElement(ctx context.Context, userID int64, listID int64, elementID int64) (ListItem, error)
+	ReorderListItems(ctx context.Context, userID int64, listID int64, itemIDs []int64) error
+	DeletedItems(ctx context.Context, userID int64, listID int64) ([]ListItem, error)
+	RestoreLastDeleted(ctx context.Context, userID int64, listID int64) error
+	RestoreAllDeleted(ctx context.Context, userID int64, listID int64) (int, error)
+	RenameItem(ctx context.Context, userID int64, itemID int64, name string) (ListItem, error)
+	EditItem(ctx context.Context, userID int64, itemID int64, edit ItemEdit) (ListItem, error)
+	SetItemDone(ctx context.Context, userID int64, listID int64, itemID int64, done bool) (ListItem, error)
+	CheckOffItem(ctx context.Context, userID int64, listID int64, itemID int64) (ListItem, error)
+	FindDuplicate(ctx context.Context, item ListItem) (ListItem, ListItem, error)
+	MergeItem(ctx context.Context, userID int64, itemID int64, intoID int64) (ListItem, error)
+	ReplaceWithDeleted(ctx context.Context, userID int64, itemID int64, deletedID int64) (ListItem, error)
+	TrashItems(ctx context.Context, userID int64, listID int64, offset int, limit int) ([]ListItem, int64, error)
+	RestoreItem(ctx context.Context, userID int64, listID int64, itemID int64) (ListItem, error)
+	ClearDoneItems(ctx context.Context, userID int64, listID int64) ([]ListItem, error)
+	ExportItems(ctx context.Context, userID int64, listID int64, withDeleted bool) ([]ListItem, error)
+}
+
+// OwnerRepository manages who has access to a list
+type OwnerRepository interface {
+	AddOwner(ctx context.Context, userID int64, listID int64, memberID int64, role Role) error
+	CheckOwner(ctx context.Context, userID int64, listID int64) error
+	GetRole(ctx context.Context, userID int64, listID int64) (Role, error)
+	CheckRole(ctx context.Context, userID int64, listID int64, required Role) error
+	SetMuted(ctx context.Context, userID int64, listID int64, muted bool) error
+	NotificationRecipients(ctx context.Context, listID int64, actorID int64) ([]int64, error)
+	ListMembers(ctx context.Context, listID int64) ([]ListOwners, error)
+	RemoveOwner(ctx context.Context, userID int64, listID int64, memberID int64) (bool, error)
+}
+
+// SettingsRepository manages per-user settings
+type SettingsRepository interface {
+	GetSelectedList(ctx context.Context, userID int64) (List, error)
+	SelectList(ctx context.Context, userID int64, listID int64) error
+	GetSettings(ctx context.Context, userID int64) (Settings, error)
+	SetNotify(ctx context.Context, userID int64, notify bool) error
+	SetSplitCommas(ctx context.Context, userID int64, split bool) error
+	SetLocale(ctx context.Context, userID int64, loc locale) error
+	SetLanguageCode(ctx context.Context, userID int64, code string) error
+}
+
+// Store is a Repository backed by a single long-lived gorm connection pool
+type Store struct {
+	db *gorm.DB
+}
+
+// openDialector picks the database driver from the environment.
+// MISTER_LISTER_POSTGRES_DSN takes precedence over MISTER_LISTER_SQLITE_DB.
+func openDialector() gorm.Dialector {
+	if dsn := os.Getenv("MISTER_LISTER_POSTGRES_DSN"); dsn != "" {
+		return postgres.Open(dsn)
+	}
+	return sqlite.Open(os.Getenv("MISTER_LISTER_SQLITE_DB"))
 }
 
-func getDb() (*gorm.DB, error) {
-	db, err := gorm.Open(sqlite.Open(os.Getenv("MISTER_LISTER_SQLITE_DB")), &gorm.Config{})
+// openStore opens the database configured in the environment, see Migrate for the schema
+func openStore() (*Store, error) {
+	db, err := gorm.Open(openDialector(), &gorm.Config{
+		// SQLite never enforced these constraints, keep Postgres behaving the same way
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
-	return db, nil
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database handle: %w", err)
+	}
+	if db.Dialector.Name() == "sqlite" {
+		// SQLite allows a single writer, so serialize access instead of failing with "database is locked"
+		sqlDB.SetMaxOpenConns(1)
+	}
+
+	return &Store{db: db}, nil
 }
 
-func getSelectedList(ctx context.Context, userID int64) (List, error) {
-	db, err := getDb()
+// Close releases the underlying connection pool
+func (s *Store) Close() error {
+	sqlDB, err := s.db.DB()
 	if err != nil {
-		return List{}, fmt.Errorf("failed to get database: %w", err)
+		return fmt.Errorf("failed to get database handle: %w", err)
 	}
+	return sqlDB.Close()
+}
 
+func (s *Store) GetSelectedList(ctx context.Context, userID int64) (List, error) {
 	var settings Settings
-	if err := db.WithContext(ctx).First(&settings, "user_id = ?", userID).Error; err != nil {
+	if err := s.db.WithContext(ctx).First(&settings, "user_id = ?", userID).Error; err != nil {
 		return List{}, fmt.Errorf("failed to get settings for user %d: %w", userID, err)
 	}
 
@@ -78,156 +249,739 @@ func getSelectedList(ctx context.Context, userID int64) (List, error) {
 	}
 
 	var list List
-	if err := db.WithContext(ctx).First(&list, "id = ?", settings.SelectedList).Error; err != nil {
+	if err := s.db.WithContext(ctx).First(&list, "id = ?", settings.SelectedList).Error; err != nil {
 		return List{}, fmt.Errorf("failed to get list %d for user %d: %w", settings.SelectedList, userID, err)
 	}
 
 	return list, nil
 }
 
-func addItem(ctx context.Context, userID int64, itemName string) error {
-	if itemName == "" {
-		return errors.New("item name cannot be empty")
+func (s *Store) SelectList(ctx context.Context, userID int64, listID int64) error {
+	return s.saveSetting(ctx, userID, "selected_list", listID)
+}
+
+func (s *Store) GetSettings(ctx context.Context, userID int64) (Settings, error) {
+	var settings Settings
+	if err := s.db.WithContext(ctx).First(&settings, "user_id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Settings{UserID: userID}, nil
+		}
+		return Settings{}, fmt.Errorf("failed to get settings for user %d: %w", userID, err)
+	}
+	return settings, nil
+}
+
+func (s *Store) SetNotify(ctx context.Context, userID int64, notify bool) error {
+	return s.saveSetting(ctx, userID, "notify", notify)
+}
+
+func (s *Store) SetSplitCommas(ctx context.Context, userID int64, split bool) error {
+	return s.saveSetting(ctx, userID, "split_commas", split)
+}
+
+// SetLocale sets the language the bot speaks to the user, an empty locale follows Telegram's
+func (s *Store) SetLocale(ctx context.Context, userID int64, loc locale) error {
+	return s.saveSetting(ctx, userID, "locale", string(loc))
+}
+
+func (s *Store) SetLanguageCode(ctx context.Context, userID int64, code string) error {
+	return s.saveSetting(ctx, userID, "language_code", code)
+}
+
+// saveSetting updates a single settings column, creating the user's settings row if needed
+func (s *Store) saveSetting(ctx context.Context, userID int64, column string, value interface{}) error {
+	settings := map[string]interface{}{"user_id": userID, column: value}
+	if err := s.db.WithContext(ctx).Model(&Settings{}).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{column}),
+		}).
+		Create(settings).Error; err != nil {
+		return fmt.Errorf("failed to update settings for user %d: %w", userID, err)
+	}
+	return nil
+}
+
+func (s *Store) GetList(ctx context.Context, listID int64) (List, error) {
+	var list List
+	if err := s.db.WithContext(ctx).First(&list, "id = ?", listID).Error; err != nil {
+		return List{}, fmt.Errorf("failed to fetch list %d: %w", listID, err)
+	}
+	return list, nil
+}
+
+// ownedLists scopes a query on lists to the ones the user owns
+func ownedLists(db *gorm.DB, userID int64) *gorm.DB {
+	return db.Model(&List{}).
+		Joins("JOIN list_owners ON list_owners.list_id = lists.id AND list_owners.deleted_at IS NULL").
+		Where("list_owners.user_id = ?", userID)
+}
+
+func (s *Store) UserLists(ctx context.Context, userID int64) ([]List, error) {
+	var lists []List
+	if err := ownedLists(s.db.WithContext(ctx), userID).
+		Order("list_owners.id ASC").
+		Find(&lists).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch lists for user %d: %w", userID, err)
 	}
+	return lists, nil
+}
 
-	db, err := getDb()
+func (s *Store) AddItem(ctx context.Context, userID int64, listID int64, itemName string) (ListItem, error) {
+	items, err := s.AddItems(ctx, userID, listID, []ListItem{{Name: itemName}})
 	if err != nil {
-		return fmt.Errorf("failed to get database: %w", err)
+		return ListItem{}, err
+	}
+	return items[0], nil
+}
+
+// AddItems appends the items to the end of the list in one transaction, keeping their order
+func (s *Store) AddItems(ctx context.Context, userID int64, listID int64, newItems []ListItem) ([]ListItem, error) {
+	if len(newItems) == 0 {
+		return nil, errors.New("no items to add")
+	}
+	for _, item := range newItems {
+		if item.Name == "" {
+			return nil, errors.New("item name cannot be empty")
+		}
+	}
+
+	if err := s.CheckRole(ctx, userID, listID, RoleEditor); err != nil {
+		return nil, err
 	}
 
-	list, err := getSelectedList(ctx, userID)
+	tx := s.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	items, err := appendItems(tx, userID, listID, newItems)
 	if err != nil {
-		return fmt.Errorf("failed to get selected list for user %d: %w", userID, err)
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	return items, nil
+}
 
-	// Find the maximum item_order value for the list to append the new item at the end
+// appendItems adds the items at the end of the list in the given order, as added by userID
+func appendItems(db *gorm.DB, userID int64, listID int64, newItems []ListItem) ([]ListItem, error) {
+	// Find the maximum item_order value for the list to append the new items at the end
 	var maxOrder struct{ Item_order int }
-	db.WithContext(ctx).Model(&ListItem{}).Select("COALESCE(MAX(item_order), 0) as item_order").
-		Where("list_id = ?", list.ID).Scan(&maxOrder)
+	if err := db.Model(&ListItem{}).Select("COALESCE(MAX(item_order), 0) as item_order").
+		Where("list_id = ?", listID).Scan(&maxOrder).Error; err != nil {
+		return nil, fmt.Errorf("failed to get item order of list %d: %w", listID, err)
+	}
 
-	item := ListItem{
-		UserID:     userID,
-		ListID:     list.ID,
-		Name:       itemName,
-		Item_order: maxOrder.Item_order + 1,
+	items := make([]ListItem, len(newItems))
+	for i, item := range newItems {
+		items[i] = ListItem{
+			UserID:     userID,
+			ListID:     listID,
+			Name:       item.Name,
+			Quantity:   item.Quantity,
+			Unit:       item.Unit,
+			Done:       item.Done,
+			DueAt:      item.DueAt,
+			Item_order: maxOrder.Item_order + i + 1,
+		}
 	}
-	if err := db.WithContext(ctx).Create(&item).Error; err != nil {
-		return fmt.Errorf("failed to create item '%s' for user %d: %w", itemName, userID, err)
+	if err := db.Create(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to create %d items for user %d: %w", len(items), userID, err)
 	}
-	return nil
+	return items, nil
 }
 
-func selectListByName(ctx context.Context, userID int64, listName string) error {
-	db, err := getDb()
-	if err != nil {
-		return fmt.Errorf("failed to get database: %w", err)
+// FindDuplicate looks for other items of the item's list with the same name, ignoring
+// case and spacing. It returns the first matching active item and the most recently
+// deleted match, either has a zero ID when there is none. Checked off active items don't
+// count, deleted ones do, since clearing checked items deletes them still checked.
+func (s *Store) FindDuplicate(ctx context.Context, item ListItem) (ListItem, ListItem, error) {
+	var candidates []ListItem
+	if err := s.db.WithContext(ctx).Unscoped().
+		Where("list_id = ? AND id <> ? AND (deleted_at IS NOT NULL OR done = ?)", item.ListID, item.ID, false).
+		Order("item_order ASC").
+		Find(&candidates).Error; err != nil {
+		return ListItem{}, ListItem{}, fmt.Errorf("failed to fetch items for list %d: %w", item.ListID, err)
+	}
+
+	var active, deleted ListItem
+	name := normalizeName(item.Name)
+	for _, c := range candidates {
+		if normalizeName(c.Name) != name {
+			continue
+		}
+		if !c.DeletedAt.Valid {
+			if active.ID == 0 {
+				active = c
+			}
+		} else if deleted.ID == 0 || c.DeletedAt.Time.After(deleted.DeletedAt.Time) {
+			deleted = c
+		}
+	}
+	return active, deleted, nil
+}
+
+// MergeItem folds a freshly added duplicate into the existing item, adding up their quantities
+func (s *Store) MergeItem(ctx context.Context, userID int64, itemID int64, intoID int64) (ListItem, error) {
+	tx := s.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var item, into ListItem
+	if err := tx.First(&item, "id = ?", itemID).Error; err != nil {
+		tx.Rollback()
+		return ListItem{}, fmt.Errorf("failed to fetch item %d: %w", itemID, err)
+	}
+	if err := tx.Where("id = ? AND list_id = ?", intoID, item.ListID).First(&into).Error; err != nil {
+		tx.Rollback()
+		return ListItem{}, fmt.Errorf("item %d does not exist in list %d: %w", intoID, item.ListID, err)
+	}
+	if item.Unit != into.Unit {
+		tx.Rollback()
+		return ListItem{}, fmt.Errorf("can't merge item %d in %s into item %d in %s", itemID, item.Unit, intoID, into.Unit)
+	}
+
+	if err := checkRole(tx, userID, item.ListID, RoleEditor); err != nil {
+		tx.Rollback()
+		return ListItem{}, err
+	}
+
+	into.Quantity = into.count() + item.count()
+	if err := tx.Model(&into).Update("quantity", into.Quantity).Error; err != nil {
+		tx.Rollback()
+		return ListItem{}, fmt.Errorf("failed to update quantity of item %d: %w", intoID, err)
+	}
+	// The duplicate only existed for a moment, it isn't worth keeping for undo
+	if err := tx.Unscoped().Delete(&item).Error; err != nil {
+		tx.Rollback()
+		return ListItem{}, fmt.Errorf("failed to delete item %d: %w", itemID, err)
 	}
 
+	if err := tx.Commit().Error; err != nil {
+		return ListItem{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return into, nil
+}
+
+// ReplaceWithDeleted drops a freshly added duplicate and brings the deleted item back
+// in its place. The restored item takes the new quantity when one was given.
+func (s *Store) ReplaceWithDeleted(ctx context.Context, userID int64, itemID int64, deletedID int64) (ListItem, error) {
+	tx := s.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var item, deleted ListItem
+	if err := tx.First(&item, "id = ?", itemID).Error; err != nil {
+		tx.Rollback()
+		return ListItem{}, fmt.Errorf("failed to fetch item %d: %w", itemID, err)
+	}
+	if err := tx.Unscoped().
+		Where("id = ? AND list_id = ? AND deleted_at IS NOT NULL", deletedID, item.ListID).
+		First(&deleted).Error; err != nil {
+		tx.Rollback()
+		return ListItem{}, fmt.Errorf("deleted item %d does not exist in list %d: %w", deletedID, item.ListID, err)
+	}
+
+	if err := checkRole(tx, userID, item.ListID, RoleEditor); err != nil {
+		tx.Rollback()
+		return ListItem{}, err
+	}
+
+	if item.Quantity > 0 {
+		deleted.Quantity, deleted.Unit = item.Quantity, item.Unit
+	}
+	if err := tx.Unscoped().Model(&deleted).Updates(map[string]interface{}{
+		"deleted_at": nil,
+		"deleted_by": 0,
+		"done":       false,
+		"quantity":   deleted.Quantity,
+		"unit":       deleted.Unit,
+		"item_order": item.Item_order,
+	}).Error; err != nil {
+		tx.Rollback()
+		return ListItem{}, fmt.Errorf("failed to restore item %d: %w", deletedID, err)
+	}
+	if err := tx.Unscoped().Delete(&item).Error; err != nil {
+		tx.Rollback()
+		return ListItem{}, fmt.Errorf("failed to delete item %d: %w", itemID, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ListItem{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return deleted, nil
+}
+
+func (s *Store) SelectListByID(ctx context.Context, userID int64, listID int64) error {
+	// Check if user is an owner of the list
+	if err := s.CheckOwner(ctx, userID, listID); err != nil {
+		return err
+	}
+
+	if _, err := s.GetList(ctx, listID); err != nil {
+		return err
+	}
+
+	return s.SelectList(ctx, userID, listID)
+}
+
+// SelectListByName selects a list by its name among the lists the user owns.
+// Only buttons sent before lists were selected by ID still use it.
+func (s *Store) SelectListByName(ctx context.Context, userID int64, listName string) error {
 	var list List
-	if err := db.WithContext(ctx).Where("name = ?", listName).First(&list).Error; err != nil {
+	if err := ownedLists(s.db.WithContext(ctx), userID).
+		Where("lists.name = ?", listName).
+		First(&list).Error; err != nil {
 		return fmt.Errorf("list '%s' not found for user %d: %w", listName, userID, err)
 	}
 
-	// Check if user is an owner of the list
-	var owner ListOwners
-	if err := db.WithContext(ctx).Where("user_id = ? AND list_id = ?", userID, list.ID).First(&owner).Error; err != nil {
-		return fmt.Errorf("user %d is not an owner of list '%s': %w", userID, listName, err)
+	return s.SelectList(ctx, userID, list.ID)
+}
+
+func (s *Store) CreateList(ctx context.Context, userID int64, listName string) (List, error) {
+	if listName == "" {
+		return List{}, errors.New("list name cannot be empty")
 	}
 
-	if err := db.WithContext(ctx).Save(&Settings{
-		UserID:       userID,
-		SelectedList: list.ID,
-	}).Error; err != nil {
-		return fmt.Errorf("failed to update settings for user %d: %w", userID, err)
+	// Check for existing list with the same name for the user
+	var count int64
+	if err := ownedLists(s.db.WithContext(ctx), userID).
+		Where("lists.name = ?", listName).
+		Count(&count).Error; err != nil {
+		return List{}, fmt.Errorf("failed to check list names for user %d: %w", userID, err)
+	}
+	if count > 0 {
+		return List{}, fmt.Errorf("list '%s' already exists for user %d", listName, userID)
+	}
+
+	return s.createListWithItems(ctx, userID, listName, nil)
+}
+
+// CloneList copies the list's items, in their order and with their check marks,
+// into a new list owned by the user, which becomes the active one
+func (s *Store) CloneList(ctx context.Context, userID int64, listID int64, name string) (List, error) {
+	if err := s.CheckOwner(ctx, userID, listID); err != nil {
+		return List{}, err
+	}
+
+	var items []ListItem
+	if err := s.db.WithContext(ctx).
+		Where("list_id = ?", listID).
+		Order("item_order ASC").
+		Find(&items).Error; err != nil {
+		return List{}, fmt.Errorf("failed to fetch items for list %d: %w", listID, err)
+	}
+
+	return s.createListWithItems(ctx, userID, name, items)
+}
+
+// ImportList creates a list owned by the user holding the imported items, all at once
+// so a failing import leaves no empty list behind. The list becomes the active one.
+func (s *Store) ImportList(ctx context.Context, userID int64, name string, items []ListItem) (List, error) {
+	return s.createListWithItems(ctx, userID, name, items)
+}
+
+// createListWithItems creates a list owned by the user holding copies of the items
+// in the given order and makes it the user's active list
+func (s *Store) createListWithItems(ctx context.Context, userID int64, name string, items []ListItem) (List, error) {
+	if name == "" {
+		return List{}, errors.New("list name cannot be empty")
+	}
+
+	tx := s.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	list := List{Name: name}
+	if err := tx.Create(&list).Error; err != nil {
+		tx.Rollback()
+		return List{}, fmt.Errorf("failed to create list '%s' for user %d: %w", name, userID, err)
+	}
+
+	if err := addOwner(tx, list.ID, userID, RoleOwner); err != nil {
+		tx.Rollback()
+		return List{}, err
+	}
+
+	if len(items) > 0 {
+		if _, err := appendItems(tx, userID, list.ID, items); err != nil {
+			tx.Rollback()
+			return List{}, err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return List{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if err := s.SelectList(ctx, userID, list.ID); err != nil {
+		return List{}, err
+	}
+	return list, nil
+}
+
+// SetTrashDays sets how many days deleted items of the list are kept, 0 restores the default
+func (s *Store) SetTrashDays(ctx context.Context, userID int64, listID int64, days int) error {
+	if err := s.CheckRole(ctx, userID, listID, RoleOwner); err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Model(&List{}).
+		Where("id = ?", listID).
+		Update("trash_days", days).Error; err != nil {
+		return fmt.Errorf("failed to set trash retention of list %d for user %d: %w", listID, userID, err)
 	}
 	return nil
 }
 
-func createList(ctx context.Context, userID int64, listName string) error {
+func (s *Store) RenameList(ctx context.Context, userID int64, listID int64, listName string) error {
 	if listName == "" {
 		return errors.New("list name cannot be empty")
 	}
 
-	db, err := getDb()
-	if err != nil {
-		return fmt.Errorf("failed to get database: %w", err)
+	if err := s.CheckRole(ctx, userID, listID, RoleOwner); err != nil {
+		return err
 	}
 
-	// Check for existing list with the same name for the user
-	var existingList List
-	if err := db.WithContext(ctx).Where("name = ?", listName).First(&existingList).Error; err == nil {
-		var owner ListOwners
-		if err := db.WithContext(ctx).Where("user_id = ? AND list_id = ?", userID, existingList.ID).First(&owner).Error; err == nil {
-			return fmt.Errorf("list '%s' already exists for user %d", listName, userID)
+	var count int64
+	if err := ownedLists(s.db.WithContext(ctx), userID).
+		Where("lists.name = ? AND lists.id <> ?", listName, listID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check list names for user %d: %w", userID, err)
+	}
+	if count > 0 {
+		return fmt.Errorf("list '%s' already exists for user %d", listName, userID)
+	}
+
+	if err := s.db.WithContext(ctx).Model(&List{}).
+		Where("id = ?", listID).
+		Update("name", listName).Error; err != nil {
+		return fmt.Errorf("failed to rename list %d for user %d: %w", listID, userID, err)
+	}
+	return nil
+}
+
+// DeleteList soft-deletes a list and moves every co-owner that had it active to another of their lists
+func (s *Store) DeleteList(ctx context.Context, userID int64, listID int64) error {
+	if err := s.CheckRole(ctx, userID, listID, RoleOwner); err != nil {
+		return err
+	}
+
+	return s.archiveList(ctx, listID)
+}
+
+func (s *Store) archiveList(ctx context.Context, listID int64) error {
+	tx := s.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
 		}
+	}()
+
+	if err := tx.Delete(&List{}, "id = ?", listID).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete list %d: %w", listID, err)
 	}
 
-	list := List{Name: listName}
-	if err := db.WithContext(ctx).Create(&list).Error; err != nil {
-		return fmt.Errorf("failed to create list '%s' for user %d: %w", listName, userID, err)
+	if err := reselectLists(tx, listID); err != nil {
+		tx.Rollback()
+		return err
 	}
 
-	if err := db.WithContext(ctx).Save(&Settings{
-		UserID:       userID,
-		SelectedList: list.ID,
-	}).Error; err != nil {
-		return fmt.Errorf("failed to update settings for user %d: %w", userID, err)
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// reselectLists points the users that have the list selected to the first other list they own.
+// Without userIDs every user that has the list selected is moved.
+func reselectLists(tx *gorm.DB, listID int64, userIDs ...int64) error {
+	query := tx.Where("selected_list = ?", listID)
+	if len(userIDs) > 0 {
+		query = query.Where("user_id IN ?", userIDs)
 	}
 
-	if err := addOwner(ctx, userID, list.ID); err != nil {
-		return fmt.Errorf("failed to add owner for list %d, user %d: %w", list.ID, userID, err)
+	var settings []Settings
+	if err := query.Find(&settings).Error; err != nil {
+		return fmt.Errorf("failed to fetch settings for list %d: %w", listID, err)
+	}
+
+	for _, setting := range settings {
+		var next []int64
+		if err := ownedLists(tx, setting.UserID).
+			Where("lists.id <> ?", listID).
+			Order("list_owners.id ASC").
+			Limit(1).
+			Pluck("lists.id", &next).Error; err != nil {
+			return fmt.Errorf("failed to find next list for user %d: %w", setting.UserID, err)
+		}
+
+		var selected int64
+		if len(next) > 0 {
+			selected = next[0]
+		}
+		if err := tx.Model(&Settings{}).
+			Where("user_id = ?", setting.UserID).
+			Update("selected_list", selected).Error; err != nil {
+			return fmt.Errorf("failed to update settings for user %d: %w", setting.UserID, err)
+		}
 	}
 	return nil
 }
 
-func addOwner(ctx context.Context, userID int64, listID int64) error {
-	db, err := getDb()
-	if err != nil {
-		return fmt.Errorf("failed to get database: %w", err)
+func (s *Store) DeletedLists(ctx context.Context, userID int64) ([]List, error) {
+	var lists []List
+	if err := ownedLists(s.db.WithContext(ctx).Unscoped(), userID).
+		Where("lists.deleted_at IS NOT NULL").
+		Order("lists.deleted_at DESC").
+		Find(&lists).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch deleted lists for user %d: %w", userID, err)
+	}
+	return lists, nil
+}
+
+// RestoreList brings back a deleted list and makes it the user's active list
+func (s *Store) RestoreList(ctx context.Context, userID int64, listID int64) (List, error) {
+	if err := s.CheckRole(ctx, userID, listID, RoleOwner); err != nil {
+		return List{}, err
+	}
+
+	if err := s.db.WithContext(ctx).Unscoped().Model(&List{}).
+		Where("id = ?", listID).
+		Update("deleted_at", nil).Error; err != nil {
+		return List{}, fmt.Errorf("failed to restore list %d for user %d: %w", listID, userID, err)
+	}
+
+	if err := s.SelectList(ctx, userID, listID); err != nil {
+		return List{}, err
+	}
+	return s.GetList(ctx, listID)
+}
+
+// AddOwner gives memberID the role on the list on behalf of userID, who must own it.
+// A member that already has access gets the new role.
+func (s *Store) AddOwner(ctx context.Context, userID int64, listID int64, memberID int64, role Role) error {
+	if _, ok := roleRanks[role]; !ok {
+		return fmt.Errorf("unknown role '%s'", role)
+	}
+
+	if err := s.CheckRole(ctx, userID, listID, RoleOwner); err != nil {
+		return err
 	}
 
+	return addOwner(s.db.WithContext(ctx), listID, memberID, role)
+}
+
+// addOwner gives the member the role on the list, changing it if the member already has one
+func addOwner(db *gorm.DB, listID int64, memberID int64, role Role) error {
 	// Check if user is already an owner
 	var existingOwner ListOwners
-	if err := db.WithContext(ctx).Where("user_id = ? AND list_id = ?", userID, listID).First(&existingOwner).Error; err == nil {
-		return nil // User is already an owner
+	if err := db.Where("user_id = ? AND list_id = ?", memberID, listID).First(&existingOwner).Error; err == nil {
+		if err := db.Model(&existingOwner).Update("role", role).Error; err != nil {
+			return fmt.Errorf("failed to update role of owner %d in list %d: %w", memberID, listID, err)
+		}
+		return nil
 	}
 
-	owner := ListOwners{UserID: userID, ListID: listID}
-	if err := db.WithContext(ctx).Create(&owner).Error; err != nil {
-		return fmt.Errorf("failed to add owner %d to list %d: %w", userID, listID, err)
+	owner := ListOwners{UserID: memberID, ListID: listID, Role: role}
+	if err := db.Create(&owner).Error; err != nil {
+		return fmt.Errorf("failed to add owner %d to list %d: %w", memberID, listID, err)
 	}
 	return nil
 }
 
-func deleteListElement(ctx context.Context, userID int64, listID int64, elementID int64) error {
-	db, err := getDb()
-	if err != nil {
-		return fmt.Errorf("failed to get database: %w", err)
-	}
+// CheckOwner checks that the user has any role on the list
+func (s *Store) CheckOwner(ctx context.Context, userID int64, listID int64) error {
+	_, err := s.GetRole(ctx, userID, listID)
+	return err
+}
+
+func (s *Store) GetRole(ctx context.Context, userID int64, listID int64) (Role, error) {
+	return getRole(s.db.WithContext(ctx), userID, listID)
+}
 
+func (s *Store) CheckRole(ctx context.Context, userID int64, listID int64, required Role) error {
+	return checkRole(s.db.WithContext(ctx), userID, listID, required)
+}
+
+// getRole and checkRole take the connection to use, so they also work inside transactions
+func getRole(db *gorm.DB, userID int64, listID int64) (Role, error) {
 	var owner ListOwners
-	if err := db.WithContext(ctx).Where("user_id = ? AND list_id = ?", userID, listID).First(&owner).Error; err != nil {
-		return fmt.Errorf("user %d is not an owner of list %d: %w", userID, listID, err)
+	if err := db.Where("user_id = ? AND list_id = ?", userID, listID).First(&owner).Error; err != nil {
+		return "", fmt.Errorf("user %d is not an owner of list %d: %w", userID, listID, err)
 	}
+	return owner.Role, nil
+}
 
-	if err := db.WithContext(ctx).
-		Where("id = ? AND list_id = ?", elementID, listID).
-		Delete(&ListItem{}).Error; err != nil {
-		return fmt.Errorf("failed to delete item %d from list %d for user %d: %w", elementID, listID, userID, err)
+func checkRole(db *gorm.DB, userID int64, listID int64, required Role) error {
+	role, err := getRole(db, userID, listID)
+	if err != nil {
+		return err
+	}
+	if !role.allows(required) {
+		return fmt.Errorf("user %d is %s of list %d, %s required: %w", userID, role, listID, required, ErrForbidden)
 	}
 	return nil
 }
 
-func getListItems(ctx context.Context, userID, listID int64) ([]ListItem, error) {
-	db, err := getDb()
+func (s *Store) ListMembers(ctx context.Context, listID int64) ([]ListOwners, error) {
+	var owners []ListOwners
+	if err := s.db.WithContext(ctx).
+		Where("list_id = ?", listID).
+		Order("id ASC").
+		Find(&owners).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch owners of list %d: %w", listID, err)
+	}
+	return owners, nil
+}
+
+// RemoveOwner takes memberID off the list on behalf of userID, who may be the member leaving.
+// The last member is never removed, the list is archived instead so it can still be restored.
+// When the last owner leaves, the oldest remaining member becomes the owner.
+// It reports whether the list was archived.
+func (s *Store) RemoveOwner(ctx context.Context, userID int64, listID int64, memberID int64) (bool, error) {
+	if memberID != userID {
+		if err := s.CheckRole(ctx, userID, listID, RoleOwner); err != nil {
+			return false, err
+		}
+	}
+	memberRole, err := s.GetRole(ctx, memberID, listID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get database: %w", err)
+		return false, err
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&ListOwners{}).Where("list_id = ?", listID).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to count owners of list %d: %w", listID, err)
+	}
+	if count <= 1 {
+		return true, s.archiveList(ctx, listID)
+	}
+
+	tx := s.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Hard delete, so sharing the list again doesn't trip the unique (user_id, list_id) index
+	if err := tx.Unscoped().
+		Where("user_id = ? AND list_id = ?", memberID, listID).
+		Delete(&ListOwners{}).Error; err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("failed to remove owner %d from list %d: %w", memberID, listID, err)
+	}
+
+	if memberRole == RoleOwner {
+		if err := promoteOwner(tx, listID); err != nil {
+			tx.Rollback()
+			return false, err
+		}
+	}
+
+	if err := reselectLists(tx, listID, memberID); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return false, nil
+}
+
+func (s *Store) SetMuted(ctx context.Context, userID int64, listID int64, muted bool) error {
+	if err := s.db.WithContext(ctx).Model(&ListOwners{}).
+		Where("user_id = ? AND list_id = ?", userID, listID).
+		Update("muted", muted).Error; err != nil {
+		return fmt.Errorf("failed to update mute of list %d for user %d: %w", listID, userID, err)
+	}
+	return nil
+}
+
+// NotificationRecipients returns the members of the list, other than the actor,
+// who turned notifications on and didn't mute the list
+func (s *Store) NotificationRecipients(ctx context.Context, listID int64, actorID int64) ([]int64, error) {
+	var userIDs []int64
+	if err := s.db.WithContext(ctx).Model(&ListOwners{}).
+		Joins("JOIN settings ON settings.user_id = list_owners.user_id").
+		Where("list_owners.list_id = ? AND list_owners.user_id <> ? AND list_owners.muted = ? AND settings.notify = ?", listID, actorID, false, true).
+		Pluck("list_owners.user_id", &userIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch notification recipients of list %d: %w", listID, err)
+	}
+	return userIDs, nil
+}
+
+// promoteOwner makes the oldest member the owner of a list that has no owner left
+func promoteOwner(tx *gorm.DB, listID int64) error {
+	var owners int64
+	if err := tx.Model(&ListOwners{}).Where("list_id = ? AND role = ?", listID, RoleOwner).Count(&owners).Error; err != nil {
+		return fmt.Errorf("failed to count owners of list %d: %w", listID, err)
+	}
+	if owners > 0 {
+		return nil
+	}
+
+	var oldest ListOwners
+	if err := tx.Where("list_id = ?", listID).Order("id ASC").First(&oldest).Error; err != nil {
+		return fmt.Errorf("failed to find oldest member of list %d: %w", listID, err)
+	}
+	if err := tx.Model(&oldest).Update("role", RoleOwner).Error; err != nil {
+		return fmt.Errorf("failed to promote user %d to owner of list %d: %w", oldest.UserID, listID, err)
+	}
+	return nil
+}
+
+func (s *Store) DeleteListElement(ctx context.Context, userID int64, listID int64, elementID int64) (ListItem, error) {
+	if err := s.CheckRole(ctx, userID, listID, RoleEditor); err != nil {
+		return ListItem{}, err
+	}
+
+	var item ListItem
+	if err := s.db.WithContext(ctx).
+		Where("id = ? AND list_id = ?", elementID, listID).
+		First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrItemNotFound
+		}
+		return ListItem{}, fmt.Errorf("item %d does not exist in list %d: %w", elementID, listID, err)
+	}
+
+	if err := deleteItems(s.db.WithContext(ctx), userID, item.ID); err != nil {
+		return ListItem{}, fmt.Errorf("failed to delete item %d from list %d for user %d: %w", elementID, listID, userID, err)
 	}
+	return item, nil
+}
+
+// deleteItems soft-deletes the items, recording who deleted them
+func deleteItems(db *gorm.DB, userID int64, itemIDs ...int64) error {
+	return db.Model(&ListItem{}).
+		Where("id IN ?", itemIDs).
+		Updates(map[string]interface{}{"deleted_at": time.Now(), "deleted_by": userID}).Error
+}
 
+func (s *Store) ListItems(ctx context.Context, listID int64) ([]ListItem, error) {
 	var items []ListItem
-	if err := db.WithContext(ctx).
+	if err := s.db.WithContext(ctx).
 		Where("list_id = ?", listID).
-		Order("item_order ASC").
+		Order("done ASC, item_order ASC").
 		Find(&items).Error; err != nil {
 		return nil, fmt.Errorf("failed to fetch items for list %d: %w", listID, err)
 	}
@@ -235,18 +989,193 @@ func getListItems(ctx context.Context, userID, listID int64) ([]ListItem, error)
 	return items, nil
 }
 
-func reorderListItems(ctx context.Context, userID int64, listID int64, itemIDs []int64) error {
-	db, err := getDb()
+// ExportItems returns the list's items in the order they are shown, followed by
+// its deleted items from the oldest deletion when withDeleted is set
+func (s *Store) ExportItems(ctx context.Context, userID int64, listID int64, withDeleted bool) ([]ListItem, error) {
+	if err := s.CheckOwner(ctx, userID, listID); err != nil {
+		return nil, err
+	}
+
+	items, err := s.ListItems(ctx, listID)
 	if err != nil {
-		return fmt.Errorf("failed to get database: %w", err)
+		return nil, err
+	}
+	if !withDeleted {
+		return items, nil
 	}
 
-	var owner ListOwners
-	if err := db.WithContext(ctx).Where("user_id = ? AND list_id = ?", userID, listID).First(&owner).Error; err != nil {
-		return fmt.Errorf("user %d is not an owner of list %d: %w", userID, listID, err)
+	var deleted []ListItem
+	if err := s.db.WithContext(ctx).Unscoped().
+		Where("list_id = ? AND deleted_at IS NOT NULL", listID).
+		Order("deleted_at ASC").
+		Find(&deleted).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch deleted items of list %d: %w", listID, err)
+	}
+	return append(items, deleted...), nil
+}
+
+// RenameItem fixes the item's text, keeping its order and author.
+// It returns the item as it was before the rename.
+func (s *Store) RenameItem(ctx context.Context, userID int64, itemID int64, name string) (ListItem, error) {
+	if name == "" {
+		return ListItem{}, errors.New("item name cannot be empty")
+	}
+
+	var item ListItem
+	if err := s.db.WithContext(ctx).First(&item, "id = ?", itemID).Error; err != nil {
+		return ListItem{}, fmt.Errorf("failed to fetch item %d: %w", itemID, err)
+	}
+
+	if err := s.CheckRole(ctx, userID, item.ListID, RoleEditor); err != nil {
+		return ListItem{}, err
+	}
+
+	if err := s.db.WithContext(ctx).Model(&ListItem{}).
+		Where("id = ?", itemID).
+		Update("name", name).Error; err != nil {
+		return ListItem{}, fmt.Errorf("failed to rename item %d for user %d: %w", itemID, userID, err)
 	}
+	return item, nil
+}
+
+// ItemEdit is a change of an item made in the web app, a nil Name keeps the text.
+// With SetDue the due date becomes Due, a nil Due clears it.
+type ItemEdit struct {
+	Name   *string
+	SetDue bool
+	Due    *time.Time
+}
 
-	tx := db.WithContext(ctx).Begin()
+// EditItem applies the edit at once, so either every field changes or none.
+// A new due date is reminded of again. It returns the item as it was before the edit.
+func (s *Store) EditItem(ctx context.Context, userID int64, itemID int64, edit ItemEdit) (ListItem, error) {
+	if edit.Name != nil && *edit.Name == "" {
+		return ListItem{}, errors.New("item name cannot be empty")
+	}
+
+	tx := s.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var item ListItem
+	if err := tx.First(&item, "id = ?", itemID).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrItemNotFound
+		}
+		return ListItem{}, fmt.Errorf("failed to fetch item %d: %w", itemID, err)
+	}
+
+	if err := checkRole(tx, userID, item.ListID, RoleEditor); err != nil {
+		tx.Rollback()
+		return ListItem{}, err
+	}
+
+	update := make(map[string]interface{})
+	if edit.Name != nil {
+		update["name"] = *edit.Name
+	}
+	if edit.SetDue {
+		update["due_at"] = edit.Due
+		update["reminded"] = false
+	}
+	if len(update) > 0 {
+		if err := tx.Model(&ListItem{}).Where("id = ?", itemID).Updates(update).Error; err != nil {
+			tx.Rollback()
+			return ListItem{}, fmt.Errorf("failed to edit item %d for user %d: %w", itemID, userID, err)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ListItem{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return item, nil
+}
+
+// SetItemDone checks an item off or back on
+func (s *Store) SetItemDone(ctx context.Context, userID int64, listID int64, itemID int64, done bool) (ListItem, error) {
+	if err := s.CheckRole(ctx, userID, listID, RoleEditor); err != nil {
+		return ListItem{}, err
+	}
+
+	var item ListItem
+	if err := s.db.WithContext(ctx).
+		Where("id = ? AND list_id = ?", itemID, listID).
+		First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrItemNotFound
+		}
+		return ListItem{}, fmt.Errorf("item %d does not exist in list %d: %w", itemID, listID, err)
+	}
+
+	if err := s.db.WithContext(ctx).Model(&item).Update("done", done).Error; err != nil {
+		return ListItem{}, fmt.Errorf("failed to mark item %d in list %d for user %d: %w", itemID, listID, userID, err)
+	}
+	return item, nil
+}
+
+// CheckOffItem takes a single piece of a counted item, so "eggs ×3" becomes "eggs ×2".
+// Items without a count, or measured in units, are checked off at once.
+func (s *Store) CheckOffItem(ctx context.Context, userID int64, listID int64, itemID int64) (ListItem, error) {
+	if err := s.CheckRole(ctx, userID, listID, RoleEditor); err != nil {
+		return ListItem{}, err
+	}
+
+	var item ListItem
+	if err := s.db.WithContext(ctx).
+		Where("id = ? AND list_id = ?", itemID, listID).
+		First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrItemNotFound
+		}
+		return ListItem{}, fmt.Errorf("item %d does not exist in list %d: %w", itemID, listID, err)
+	}
+
+	update := map[string]interface{}{"done": true}
+	if item.Unit == "" && item.Quantity > 1 {
+		update = map[string]interface{}{"quantity": item.Quantity - 1}
+	}
+	if err := s.db.WithContext(ctx).Model(&item).Updates(update).Error; err != nil {
+		return ListItem{}, fmt.Errorf("failed to check off item %d in list %d for user %d: %w", itemID, listID, userID, err)
+	}
+	return item, nil
+}
+
+// ClearDoneItems deletes the checked off items, they can be restored like any other deleted item
+func (s *Store) ClearDoneItems(ctx context.Context, userID int64, listID int64) ([]ListItem, error) {
+	if err := s.CheckRole(ctx, userID, listID, RoleEditor); err != nil {
+		return nil, err
+	}
+
+	var items []ListItem
+	if err := s.db.WithContext(ctx).
+		Where("list_id = ? AND done = ?", listID, true).
+		Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch done items for list %d: %w", listID, err)
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	itemIDs := make([]int64, len(items))
+	for i, item := range items {
+		itemIDs[i] = item.ID
+	}
+	if err := deleteItems(s.db.WithContext(ctx), userID, itemIDs...); err != nil {
+		return nil, fmt.Errorf("failed to clear done items from list %d for user %d: %w", listID, userID, err)
+	}
+	return items, nil
+}
+
+func (s *Store) ReorderListItems(ctx context.Context, userID int64, listID int64, itemIDs []int64) error {
+	if err := s.CheckRole(ctx, userID, listID, RoleEditor); err != nil {
+		return err
+	}
+
+	tx := s.db.WithContext(ctx).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
@@ -255,7 +1184,7 @@ func reorderListItems(ctx context.Context, userID int64, listID int64, itemIDs [
 
 	for _, itemID := range itemIDs {
 		var item ListItem
-		if err := tx.WithContext(ctx).Where("id = ? AND list_id = ?", itemID, listID).First(&item).Error; err != nil {
+		if err := tx.Where("id = ? AND list_id = ?", itemID, listID).First(&item).Error; err != nil {
 			tx.Rollback()
 			return fmt.Errorf("item %d does not exist in list %d: %w", itemID, listID, err)
 		}
@@ -275,3 +1204,164 @@ func reorderListItems(ctx context.Context, userID int64, listID int64, itemIDs [
 	}
 	return nil
 }
+
+// deletedByUser matches items the user deleted. Items deleted before deleters were
+// recorded are matched by their author, as undo used to work.
+const deletedByUser = "deleted_at IS NOT NULL AND (deleted_by = ? OR (deleted_by = 0 AND user_id = ?))"
+
+// DeletedItems returns the items of the list the user deleted
+func (s *Store) DeletedItems(ctx context.Context, userID int64, listID int64) ([]ListItem, error) {
+	var deletedItems []ListItem
+	if err := s.db.WithContext(ctx).Unscoped().
+		Where("list_id = ?", listID).
+		Where(deletedByUser, userID, userID).
+		Order("item_order ASC").
+		Find(&deletedItems).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch deleted items for user %d, list %d: %w", userID, listID, err)
+	}
+	return deletedItems, nil
+}
+
+// TrashItems returns a page of the list's deleted items, most recently deleted first,
+// along with how many deleted items there are in total
+func (s *Store) TrashItems(ctx context.Context, userID int64, listID int64, offset int, limit int) ([]ListItem, int64, error) {
+	if err := s.CheckOwner(ctx, userID, listID); err != nil {
+		return nil, 0, err
+	}
+
+	query := s.db.WithContext(ctx).Unscoped().Model(&ListItem{}).
+		Where("list_id = ? AND deleted_at IS NOT NULL", listID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count deleted items of list %d: %w", listID, err)
+	}
+
+	var items []ListItem
+	if err := query.Order("deleted_at DESC").Offset(offset).Limit(limit).Find(&items).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch deleted items of list %d: %w", listID, err)
+	}
+	return items, total, nil
+}
+
+// RestoreItem brings a deleted item back at its old place in the list
+func (s *Store) RestoreItem(ctx context.Context, userID int64, listID int64, itemID int64) (ListItem, error) {
+	if err := s.CheckRole(ctx, userID, listID, RoleEditor); err != nil {
+		return ListItem{}, err
+	}
+
+	tx := s.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var item ListItem
+	if err := tx.Unscoped().
+		Where("id = ? AND list_id = ? AND deleted_at IS NOT NULL", itemID, listID).
+		First(&item).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrItemNotFound
+		}
+		return ListItem{}, fmt.Errorf("deleted item %d does not exist in list %d: %w", itemID, listID, err)
+	}
+
+	if err := restoreItem(tx, item); err != nil {
+		tx.Rollback()
+		return ListItem{}, fmt.Errorf("failed to restore item %d for user %d, list %d: %w", itemID, userID, listID, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ListItem{}, fmt.Errorf("failed to commit transaction for user %d, list %d: %w", userID, listID, err)
+	}
+	return item, nil
+}
+
+// RestoreLastDeleted brings back the item the user deleted last, whoever added it
+func (s *Store) RestoreLastDeleted(ctx context.Context, userID int64, listID int64) error {
+	if err := s.CheckRole(ctx, userID, listID, RoleEditor); err != nil {
+		return err
+	}
+
+	tx := s.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var lastDeleted ListItem
+	if err := tx.Unscoped().
+		Where("list_id = ?", listID).
+		Where(deletedByUser, userID, userID).
+		Order("deleted_at DESC").
+		First(&lastDeleted).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNothingToRestore
+		}
+		return fmt.Errorf("failed to fetch last deleted item for user %d, list %d: %w", userID, listID, err)
+	}
+
+	if err := restoreItem(tx, lastDeleted); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to restore item %d for user %d, list %d: %w", lastDeleted.ID, userID, listID, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("failed to commit transaction for user %d, list %d: %w", userID, listID, err)
+	}
+	return nil
+}
+
+// RestoreAllDeleted brings back every item of the list the user deleted
+func (s *Store) RestoreAllDeleted(ctx context.Context, userID int64, listID int64) (int, error) {
+	if err := s.CheckRole(ctx, userID, listID, RoleEditor); err != nil {
+		return 0, err
+	}
+
+	tx := s.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var deletedItems []ListItem
+	if err := tx.Unscoped().
+		Where("list_id = ?", listID).
+		Where(deletedByUser, userID, userID).
+		Order("item_order ASC").
+		Find(&deletedItems).Error; err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to fetch deleted items for user %d, list %d: %w", userID, listID, err)
+	}
+
+	// Restoring in ascending order puts every item back at its original position
+	for _, item := range deletedItems {
+		if err := restoreItem(tx, item); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to restore item %d for user %d, list %d: %w", item.ID, userID, listID, err)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return 0, fmt.Errorf("failed to commit transaction for user %d, list %d: %w", userID, listID, err)
+	}
+	return len(deletedItems), nil
+}
+
+// restoreItem undeletes the item at its item_order, shifting the items from there on down
+func restoreItem(tx *gorm.DB, item ListItem) error {
+	if err := tx.Model(&ListItem{}).
+		Where("list_id = ? AND item_order >= ?", item.ListID, item.Item_order).
+		Update("item_order", gorm.Expr("item_order + 1")).Error; err != nil {
+		return fmt.Errorf("failed to shift items of list %d: %w", item.ListID, err)
+	}
+
+	return tx.Unscoped().Model(&ListItem{}).
+		Where("id = ?", item.ID).
+		Updates(map[string]interface{}{"deleted_at": nil, "deleted_by": 0}).Error
+}
@@ -0,0 +1,544 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// forEachDialect runs test against an empty, migrated Store on every database the bot
+// supports. SQLite runs in memory. Postgres runs only when MISTER_LISTER_POSTGRES_DSN
+// points at a local instance, in a schema of its own that is dropped afterwards.
+func forEachDialect(t *testing.T, test func(t *testing.T, s *Store)) {
+	postgresDSN := os.Getenv("MISTER_LISTER_POSTGRES_DSN")
+
+	t.Run("sqlite", func(t *testing.T) {
+		t.Setenv("MISTER_LISTER_POSTGRES_DSN", "")
+		t.Setenv("MISTER_LISTER_SQLITE_DB", ":memory:")
+		test(t, openTestStore(t, ""))
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		if postgresDSN == "" {
+			t.Skip("MISTER_LISTER_POSTGRES_DSN is not set")
+		}
+		t.Setenv("MISTER_LISTER_POSTGRES_DSN", postgresDSN)
+		test(t, openTestStore(t, fmt.Sprintf("mister_lister_test_%d", time.Now().UnixNano())))
+	})
+}
+
+// openTestStore opens the configured database and migrates it. A non-empty schema is
+// created and used for the whole test, so the store is kept to a single connection.
+func openTestStore(t *testing.T, schema string) *Store {
+	t.Helper()
+
+	s, err := openStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	if schema != "" {
+		sqlDB, err := s.db.DB()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sqlDB.SetMaxOpenConns(1)
+		if err := s.db.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.db.Exec("DROP SCHEMA " + schema + " CASCADE") })
+		if err := s.db.Exec("SET search_path TO " + schema).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.Migrate(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestMigrate(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, s *Store) {
+		ctx := context.Background()
+
+		var version int
+		if err := s.db.Model(&SchemaVersion{}).Select("MAX(version)").Scan(&version).Error; err != nil {
+			t.Fatal(err)
+		}
+		if want := migrations[len(migrations)-1].version; version != want {
+			t.Errorf("schema version = %d, want %d", version, want)
+		}
+
+		// A migrated database has nothing left to apply
+		if err := s.Migrate(ctx, false); err != nil {
+			t.Errorf("second Migrate: %v", err)
+		}
+		if err := s.Migrate(ctx, true); err != nil {
+			t.Errorf("dry run Migrate: %v", err)
+		}
+	})
+}
+
+func TestMigrateDryRun(t *testing.T) {
+	t.Setenv("MISTER_LISTER_POSTGRES_DSN", "")
+	t.Setenv("MISTER_LISTER_SQLITE_DB", ":memory:")
+
+	s, err := openStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.Migrate(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	if s.db.Migrator().HasTable(&SchemaVersion{}) || s.db.Migrator().HasTable(&List{}) {
+		t.Error("dry run left tables behind")
+	}
+}
+
+func TestStoreLists(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, s *Store) {
+		ctx := context.Background()
+
+		groceries, err := s.CreateList(ctx, 1, "Groceries")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.CreateList(ctx, 1, "Groceries"); err == nil {
+			t.Error("CreateList allowed a second list with the same name")
+		}
+		// Names only have to be unique among the user's own lists
+		if _, err := s.CreateList(ctx, 2, "Groceries"); err != nil {
+			t.Errorf("CreateList for another user: %v", err)
+		}
+		if _, err := s.CreateList(ctx, 2, "Groceries"); err == nil {
+			t.Error("CreateList allowed a second list with the same name when another user has one")
+		}
+		if err := s.AddOwner(ctx, 1, groceries.ID, 3, RoleEditor); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.CreateList(ctx, 3, "Groceries"); err == nil {
+			t.Error("CreateList allowed a name of a shared list")
+		}
+		chores, err := s.CreateList(ctx, 1, "Chores")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		selected, err := s.GetSelectedList(ctx, 1)
+		if err != nil || selected.ID != chores.ID {
+			t.Errorf("selected list = %d, %v, want %d", selected.ID, err, chores.ID)
+		}
+
+		if err := s.RenameList(ctx, 1, groceries.ID, "Food"); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.RenameList(ctx, 1, chores.ID, "Food"); err == nil {
+			t.Error("RenameList allowed a second list with the same name")
+		}
+
+		lists, err := s.UserLists(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(lists) != 2 || lists[0].Name != "Food" || lists[1].Name != "Chores" {
+			t.Errorf("UserLists = %+v", lists)
+		}
+
+		if err := s.DeleteList(ctx, 1, chores.ID); err != nil {
+			t.Fatal(err)
+		}
+		if selected, err := s.GetSelectedList(ctx, 1); err != nil || selected.ID != groceries.ID {
+			t.Errorf("selected list after delete = %d, %v, want %d", selected.ID, err, groceries.ID)
+		}
+		deleted, err := s.DeletedLists(ctx, 1)
+		if err != nil || len(deleted) != 1 || deleted[0].ID != chores.ID {
+			t.Fatalf("DeletedLists = %+v, %v", deleted, err)
+		}
+
+		if _, err := s.RestoreList(ctx, 1, chores.ID); err != nil {
+			t.Fatal(err)
+		}
+		if lists, _ := s.UserLists(ctx, 1); len(lists) != 2 {
+			t.Errorf("UserLists after restore = %+v", lists)
+		}
+	})
+}
+
+func TestStoreItems(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, s *Store) {
+		ctx := context.Background()
+
+		list, err := s.CreateList(ctx, 1, "Groceries")
+		if err != nil {
+			t.Fatal(err)
+		}
+		added, err := s.AddItems(ctx, 1, list.ID, []ListItem{{Name: "milk"}, {Name: "bread"}, {Name: "eggs"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := s.RenameItem(ctx, 1, added[1].ID, "rye bread"); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.ReorderListItems(ctx, 1, list.ID, []int64{added[2].ID, added[0].ID, added[1].ID}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.DeleteListElement(ctx, 1, list.ID, added[0].ID); err != nil {
+			t.Fatal(err)
+		}
+
+		items, err := s.ListItems(ctx, list.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := itemNames(items); got != "eggs, rye bread" {
+			t.Errorf("items = %s, want eggs, rye bread", got)
+		}
+
+		if err := s.RestoreLastDeleted(ctx, 1, list.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.RestoreLastDeleted(ctx, 1, list.ID); !errors.Is(err, ErrNothingToRestore) {
+			t.Errorf("second RestoreLastDeleted = %v, want ErrNothingToRestore", err)
+		}
+		if items, _ := s.ListItems(ctx, list.ID); len(items) != 3 {
+			t.Errorf("items after restore = %s", itemNames(items))
+		}
+
+		// A missing item is only reported as such to members of the list
+		if _, err := s.DeleteListElement(ctx, 1, list.ID, 1000); !errors.Is(err, ErrItemNotFound) {
+			t.Errorf("DeleteListElement of a missing item = %v, want ErrItemNotFound", err)
+		}
+		if _, err := s.DeleteListElement(ctx, 2, list.ID, 1000); err == nil || errors.Is(err, ErrItemNotFound) {
+			t.Errorf("DeleteListElement by a non-member = %v, want a role error", err)
+		}
+		if _, err := s.CheckOffItem(ctx, 1, list.ID, 1000); !errors.Is(err, ErrItemNotFound) {
+			t.Errorf("CheckOffItem of a missing item = %v, want ErrItemNotFound", err)
+		}
+		if _, err := s.SetItemDone(ctx, 2, list.ID, 1000, false); err == nil || errors.Is(err, ErrItemNotFound) {
+			t.Errorf("SetItemDone by a non-member = %v, want a role error", err)
+		}
+	})
+}
+
+func TestStoreEditItem(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, s *Store) {
+		ctx := context.Background()
+
+		list, err := s.CreateList(ctx, 1, "Groceries")
+		if err != nil {
+			t.Fatal(err)
+		}
+		milk, err := s.AddItem(ctx, 1, list.ID, "milk")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.AddOwner(ctx, 1, list.ID, 2, RoleViewer); err != nil {
+			t.Fatal(err)
+		}
+
+		name := "oat milk"
+		due := time.Date(2026, 10, 17, 9, 0, 0, 0, time.Local)
+		if _, err := s.EditItem(ctx, 2, milk.ID, ItemEdit{Name: &name, SetDue: true, Due: &due}); !errors.Is(err, ErrForbidden) {
+			t.Errorf("viewer EditItem = %v, want ErrForbidden", err)
+		}
+		if _, err := s.EditItem(ctx, 1, 1000, ItemEdit{Name: &name}); !errors.Is(err, ErrItemNotFound) {
+			t.Errorf("EditItem of a missing item = %v, want ErrItemNotFound", err)
+		}
+
+		before, err := s.EditItem(ctx, 1, milk.ID, ItemEdit{Name: &name, SetDue: true, Due: &due})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if before.Name != "milk" {
+			t.Errorf("EditItem returned %q, want the old name", before.Name)
+		}
+		items, err := s.ListItems(ctx, list.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if items[0].Name != name || items[0].DueAt == nil || !items[0].DueAt.Equal(due) {
+			t.Errorf("item = %q due %v, want %q due %v", items[0].Name, items[0].DueAt, name, due)
+		}
+
+		if _, err := s.EditItem(ctx, 1, milk.ID, ItemEdit{SetDue: true}); err != nil {
+			t.Fatal(err)
+		}
+		if items, _ := s.ListItems(ctx, list.ID); items[0].DueAt != nil || items[0].Name != name {
+			t.Errorf("item after clearing the due date = %q due %v", items[0].Name, items[0].DueAt)
+		}
+	})
+}
+
+func TestStoreReminders(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, s *Store) {
+		ctx := context.Background()
+		now := time.Now()
+
+		list, err := s.CreateList(ctx, 1, "Groceries")
+		if err != nil {
+			t.Fatal(err)
+		}
+		milk, err := s.AddItem(ctx, 1, list.ID, "milk")
+		if err != nil {
+			t.Fatal(err)
+		}
+		due := now.Add(-time.Minute)
+		if _, err := s.EditItem(ctx, 1, milk.ID, ItemEdit{SetDue: true, Due: &due}); err != nil {
+			t.Fatal(err)
+		}
+
+		items, err := s.DueItems(ctx, now)
+		if err != nil || len(items) != 1 {
+			t.Fatalf("DueItems = %+v, %v", items, err)
+		}
+
+		// Two bots see the same due item, only the first one reminds of it
+		if claimed, err := s.ClaimReminder(ctx, milk.ID); err != nil || !claimed {
+			t.Fatalf("ClaimReminder = %v, %v, want claimed", claimed, err)
+		}
+		if claimed, err := s.ClaimReminder(ctx, milk.ID); err != nil || claimed {
+			t.Errorf("second ClaimReminder = %v, %v, want not claimed", claimed, err)
+		}
+		if items, _ := s.DueItems(ctx, now); len(items) != 0 {
+			t.Errorf("DueItems after the claim = %+v", items)
+		}
+		if items, _ := s.OverdueItems(ctx, 1, now); len(items) != 1 {
+			t.Errorf("OverdueItems = %+v", items)
+		}
+	})
+}
+
+func TestStoreImportList(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, s *Store) {
+		ctx := context.Background()
+
+		list, err := s.ImportList(ctx, 1, "Imported", []ListItem{{Name: "milk", Quantity: 2, Unit: "l"}, {Name: "bread", Done: true}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if selected, err := s.GetSelectedList(ctx, 1); err != nil || selected.ID != list.ID {
+			t.Errorf("selected list = %d, %v, want %d", selected.ID, err, list.ID)
+		}
+		items, err := s.ListItems(ctx, list.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items) != 2 || items[0].Label() != "milk 2 l" || !items[1].Done {
+			t.Errorf("items = %+v", items)
+		}
+	})
+}
+
+func TestStorePendingImports(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, s *Store) {
+		ctx := context.Background()
+		imported := importedList{Title: "Recipe", Items: []ListItem{{Name: "flour", Quantity: 500, Unit: "g"}, {Name: "eggs", Quantity: 3}}}
+
+		first, err := s.SavePendingImport(ctx, 1, 7, imported)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := s.SavePendingImport(ctx, 1, 7, imported)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// A newer file replaces the preview, the buttons of the old one stop working
+		if _, _, err := s.TakePendingImport(ctx, 1, first); !errors.Is(err, ErrNoPendingImport) {
+			t.Errorf("take of a replaced import = %v, want ErrNoPendingImport", err)
+		}
+		if _, _, err := s.TakePendingImport(ctx, 2, second); !errors.Is(err, ErrNoPendingImport) {
+			t.Errorf("take by another user = %v, want ErrNoPendingImport", err)
+		}
+
+		listID, got, err := s.TakePendingImport(ctx, 1, second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if listID != 7 || got.Title != "Recipe" || len(got.Items) != 2 || got.Items[0].Label() != "flour 500 g" || got.Items[1].Label() != "eggs ×3" {
+			t.Errorf("TakePendingImport = %d, %+v", listID, got)
+		}
+		if _, _, err := s.TakePendingImport(ctx, 1, second); !errors.Is(err, ErrNoPendingImport) {
+			t.Errorf("second take = %v, want ErrNoPendingImport", err)
+		}
+
+		expired, err := s.SavePendingImport(ctx, 1, 0, imported)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.db.Model(&PendingImport{}).Where("user_id = ?", 1).Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := s.TakePendingImport(ctx, 1, expired); !errors.Is(err, ErrNoPendingImport) {
+			t.Errorf("take of an expired import = %v, want ErrNoPendingImport", err)
+		}
+	})
+}
+
+func TestStoreFindDuplicate(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, s *Store) {
+		ctx := context.Background()
+
+		list, err := s.CreateList(ctx, 1, "Groceries")
+		if err != nil {
+			t.Fatal(err)
+		}
+		added, err := s.AddItems(ctx, 1, list.ID, []ListItem{{Name: "milk"}, {Name: "bread"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Cleared items are deleted while still checked off and can still be restored
+		if _, err := s.SetItemDone(ctx, 1, list.ID, added[0].ID, true); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.ClearDoneItems(ctx, 1, list.ID); err != nil {
+			t.Fatal(err)
+		}
+		milk, err := s.AddItem(ctx, 1, list.ID, " Milk")
+		if err != nil {
+			t.Fatal(err)
+		}
+		active, deleted, err := s.FindDuplicate(ctx, milk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if active.ID != 0 || deleted.ID != added[0].ID {
+			t.Errorf("FindDuplicate = %d, %d, want 0, %d", active.ID, deleted.ID, added[0].ID)
+		}
+
+		// A checked off item still in the list is not a duplicate
+		if _, err := s.SetItemDone(ctx, 1, list.ID, added[1].ID, true); err != nil {
+			t.Fatal(err)
+		}
+		bread, err := s.AddItem(ctx, 1, list.ID, "bread")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if active, deleted, err := s.FindDuplicate(ctx, bread); err != nil || active.ID != 0 || deleted.ID != 0 {
+			t.Errorf("FindDuplicate = %d, %d, %v, want no match", active.ID, deleted.ID, err)
+		}
+	})
+}
+
+func TestStoreSchedules(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, s *Store) {
+		ctx := context.Background()
+		now := time.Date(2026, 10, 17, 9, 0, 30, 0, time.Local)
+
+		list, err := s.CreateList(ctx, 1, "Groceries")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.AddItems(ctx, 1, list.ID, []ListItem{{Name: "milk", Done: true}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.AddOwner(ctx, 1, list.ID, 2, RoleOwner); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.SetSchedule(ctx, 2, ListSchedule{ListID: list.ID, Rule: "0 9 * * 6", NextRun: now.Add(-time.Minute), Since: now.AddDate(0, 0, -7)}); err != nil {
+			t.Fatal(err)
+		}
+
+		due, err := s.DueSchedules(ctx, now)
+		if err != nil || len(due) != 1 {
+			t.Fatalf("DueSchedules = %+v, %v", due, err)
+		}
+
+		// Two bots see the same due schedule, only the first one claims it
+		next := now.AddDate(0, 0, 7)
+		if claimed, err := s.ClaimSchedule(ctx, due[0], next); err != nil || !claimed {
+			t.Fatalf("ClaimSchedule = %v, %v, want claimed", claimed, err)
+		}
+		if claimed, err := s.ClaimSchedule(ctx, due[0], next); err != nil || claimed {
+			t.Errorf("second ClaimSchedule = %v, %v, want not claimed", claimed, err)
+		}
+		if due, _ := s.DueSchedules(ctx, now); len(due) != 0 {
+			t.Errorf("DueSchedules after the claim = %+v", due)
+		}
+
+		if count, err := s.ResetList(ctx, due[0], now); err != nil || count != 1 {
+			t.Errorf("ResetList = %d, %v, want 1 item changed", count, err)
+		}
+
+		// Once the creator is no longer an owner the schedule stops
+		if err := s.AddOwner(ctx, 1, list.ID, 2, RoleEditor); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.SetItemDone(ctx, 1, list.ID, 1, true); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.ResetList(ctx, due[0], now); !errors.Is(err, ErrScheduleDisabled) {
+			t.Errorf("ResetList by a former owner = %v, want ErrScheduleDisabled", err)
+		}
+		if _, err := s.Schedule(ctx, 1, list.ID); err == nil {
+			t.Error("schedule of a former owner was kept")
+		}
+		if items, _ := s.ListItems(ctx, list.ID); len(items) != 1 || !items[0].Done {
+			t.Errorf("items after a disabled reset = %+v", items)
+		}
+	})
+}
+
+func TestStoreRoles(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, s *Store) {
+		ctx := context.Background()
+
+		list, err := s.CreateList(ctx, 1, "Groceries")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.AddOwner(ctx, 1, list.ID, 2, RoleViewer); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.AddOwner(ctx, 2, list.ID, 3, RoleEditor); !errors.Is(err, ErrForbidden) {
+			t.Errorf("viewer AddOwner = %v, want ErrForbidden", err)
+		}
+		if _, err := s.AddItem(ctx, 2, list.ID, "milk"); !errors.Is(err, ErrForbidden) {
+			t.Errorf("viewer AddItem = %v, want ErrForbidden", err)
+		}
+		if _, err := s.AddItem(ctx, 4, list.ID, "milk"); err == nil {
+			t.Error("AddItem allowed a user without access")
+		}
+
+		if err := s.AddOwner(ctx, 1, list.ID, 2, RoleEditor); err != nil {
+			t.Fatal(err)
+		}
+		if role, err := s.GetRole(ctx, 2, list.ID); err != nil || role != RoleEditor {
+			t.Errorf("role = %q, %v, want editor", role, err)
+		}
+		if _, err := s.AddItem(ctx, 2, list.ID, "milk"); err != nil {
+			t.Errorf("editor AddItem: %v", err)
+		}
+		if err := s.DeleteList(ctx, 2, list.ID); !errors.Is(err, ErrForbidden) {
+			t.Errorf("editor DeleteList = %v, want ErrForbidden", err)
+		}
+
+		archived, err := s.RemoveOwner(ctx, 1, list.ID, 2)
+		if err != nil || archived {
+			t.Fatalf("RemoveOwner = %v, %v", archived, err)
+		}
+		if _, err := s.GetRole(ctx, 2, list.ID); err == nil {
+			t.Error("removed member still has a role")
+		}
+	})
+}
+
+func itemNames(items []ListItem) string {
+	var names string
+	for i, item := range items {
+		if i > 0 {
+			names += ", "
+		}
+		names += item.Name
+	}
+	return names
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// dueHandler shows the overdue items of all the user's lists
+func (a *app) dueHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	items, err := a.store.OverdueItems(ctx, userID, time.Now())
+	if err != nil {
+		errorLog.Printf("Failed to get overdue items of user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrOverdue))
+		return
+	}
+	if len(items) == 0 {
+		sendMessage(ctx, b, userID, tr(ctx, MsgNoOverdue))
+		return
+	}
+
+	lines := []string{tr(ctx, MsgOverdue)}
+	for _, item := range items {
+		lines = append(lines, tr(ctx, MsgOverdueItem, item.Label(), item.List.Name, item.DueAt.Local().Format(timeLayout)))
+	}
+	sendMessage(ctx, b, userID, strings.Join(lines, "\n"))
+}
+
+// dueLabel is the item's label followed by its due date, if it has one
+func dueLabel(ctx context.Context, item ListItem) string {
+	if item.DueAt == nil {
+		return item.Label()
+	}
+	return item.Label() + tr(ctx, MsgDueSuffix, item.DueAt.Local().Format(timeLayout))
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"gorm.io/gorm"
+)
+
+// offerDuplicate asks what to do when a just added item is already on the list,
+// or was on it before and got deleted
+func (a *app) offerDuplicate(ctx context.Context, b *bot.Bot, userID int64, item ListItem) {
+	active, deleted, err := a.store.FindDuplicate(ctx, item)
+	if err != nil {
+		errorLog.Printf("Failed to look for duplicates of item %d for user %d: %v", item.ID, userID, err)
+		return
+	}
+
+	// Quantities in different units can't be added up
+	canMerge := active.ID != 0 && active.Unit == item.Unit
+	if !canMerge && deleted.ID == 0 {
+		return
+	}
+
+	var text string
+	row := []models.InlineKeyboardButton{
+		{Text: tr(ctx, BtnKeepBoth), CallbackData: fmt.Sprintf("dupKeep_%d", item.ID)},
+	}
+	if canMerge {
+		text = tr(ctx, MsgDuplicateFound, item.Label(), active.Label())
+		row = append(row, models.InlineKeyboardButton{
+			Text: tr(ctx, BtnMerge), CallbackData: fmt.Sprintf("dupMerge_%d_%d", item.ID, active.ID),
+		})
+	} else {
+		text = tr(ctx, MsgDuplicateDeleted, item.Label())
+	}
+	if deleted.ID != 0 {
+		row = append(row, models.InlineKeyboardButton{
+			Text: tr(ctx, BtnRestoreDeleted), CallbackData: fmt.Sprintf("dupRestore_%d_%d", item.ID, deleted.ID),
+		})
+	}
+
+	sendInlineKeyboard(ctx, b, userID, text, &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{row},
+	})
+}
+
+func (a *app) onDuplicateKeep(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if err := answerCallback(ctx, b, update); err != nil {
+		return
+	}
+
+	if update.CallbackQuery == nil || update.CallbackQuery.Message == nil {
+		return
+	}
+
+	editMessage(ctx, b, update.CallbackQuery.Message, tr(ctx, MsgDuplicateKept))
+}
+
+func (a *app) onDuplicateMerge(ctx context.Context, b *bot.Bot, update *models.Update) {
+	a.resolveDuplicate(ctx, b, update, "dupMerge_", a.store.MergeItem, tr(ctx, MsgDuplicateMerged))
+}
+
+func (a *app) onDuplicateRestore(ctx context.Context, b *bot.Bot, update *models.Update) {
+	a.resolveDuplicate(ctx, b, update, "dupRestore_", a.store.ReplaceWithDeleted, tr(ctx, MsgDuplicateRestored))
+}
+
+// resolveDuplicate handles the "<prefix><item>_<match>" callbacks of offerDuplicate
+// and replaces the question with the outcome
+func (a *app) resolveDuplicate(ctx context.Context, b *bot.Bot, update *models.Update, prefix string,
+	resolve func(ctx context.Context, userID int64, itemID int64, matchID int64) (ListItem, error), done string) {
+	if err := answerCallback(ctx, b, update); err != nil {
+		return
+	}
+
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.CallbackQuery == nil || update.CallbackQuery.Message == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	ids, ok := strings.CutPrefix(update.CallbackQuery.Data, prefix)
+	if !ok {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+	parts := strings.Split(ids, "_")
+	if len(parts) != 2 {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	itemID, err := parseInt64(parts[0])
+	if err != nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidID))
+		return
+	}
+
+	matchID, err := parseInt64(parts[1])
+	if err != nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidID))
+		return
+	}
+
+	item, err := resolve(ctx, userID, itemID, matchID)
+	if err != nil {
+		errorLog.Printf("Failed to resolve duplicate item %d of %d for user %d: %v", itemID, matchID, userID, err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// The item or its match was deleted meanwhile, or the question was answered already
+			editMessage(ctx, b, update.CallbackQuery.Message, tr(ctx, ErrDuplicateGone))
+			return
+		}
+		sendStoreError(ctx, b, userID, err, ErrResolveDuplicate)
+		return
+	}
+	a.refresher.ListChanged(item.ListID)
+
+	editMessage(ctx, b, update.CallbackQuery.Message, fmt.Sprintf(done, item.Label()))
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+)
+
+// exportFormats are the formats /export and /api/export understand, the first one is the default
+var exportFormats = []string{"txt", "md", "csv", "json"}
+
+var exportContentTypes = map[string]string{
+	"txt":  "text/plain; charset=utf-8",
+	"md":   "text/markdown; charset=utf-8",
+	"csv":  "text/csv; charset=utf-8",
+	"json": "application/json",
+}
+
+// csvHeader lists the columns of a CSV export, importing reads them by name
+var csvHeader = []string{"position", "name", "quantity", "unit", "done", "author_id", "author", "added_at", "deleted_at", "deleted_by_id", "deleted_by"}
+
+// ExportedList is a list as written by the JSON export
+type ExportedList struct {
+	Name       string         `json:"name"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Items      []ExportedItem `json:"items"`
+}
+
+// ExportedItem is an item of an exported list. Deleted items have no position.
+type ExportedItem struct {
+	Position    int        `json:"position,omitempty"`
+	Name        string     `json:"name"`
+	Quantity    float64    `json:"quantity,omitempty"`
+	Unit        string     `json:"unit,omitempty"`
+	Done        bool       `json:"done"`
+	AuthorID    int64      `json:"author_id"`
+	Author      string     `json:"author"`
+	AddedAt     time.Time  `json:"added_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	DeletedByID int64      `json:"deleted_by_id,omitempty"`
+	DeletedBy   string     `json:"deleted_by,omitempty"`
+}
+
+// Label is the item as shown to users, see ListItem.Label
+func (i ExportedItem) Label() string {
+	return ListItem{Name: i.Name, Quantity: i.Quantity, Unit: i.Unit}.Label()
+}
+
+// isExportFormat reports whether format is one of exportFormats
+func isExportFormat(format string) bool {
+	for _, f := range exportFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// buildExport puts the items of the list together with the names of the people who added
+// and deleted them. Items are expected in the order ExportItems returns them.
+func buildExport(ctx context.Context, b *bot.Bot, list List, items []ListItem) ExportedList {
+	names := make(map[int64]string)
+	name := func(userID int64) string {
+		if _, ok := names[userID]; !ok {
+			names[userID] = userDisplayName(ctx, b, userID)
+		}
+		return names[userID]
+	}
+
+	export := ExportedList{Name: list.Name, ExportedAt: time.Now(), Items: []ExportedItem{}}
+	position := 0
+	for _, item := range items {
+		exported := ExportedItem{
+			Name:     item.Name,
+			Quantity: item.Quantity,
+			Unit:     item.Unit,
+			Done:     item.Done,
+			AuthorID: item.UserID,
+			Author:   name(item.UserID),
+			AddedAt:  item.CreatedAt,
+		}
+		if item.DeletedAt.Valid {
+			deletedAt := item.DeletedAt.Time
+			exported.DeletedAt = &deletedAt
+			if item.DeletedBy != 0 {
+				exported.DeletedByID = item.DeletedBy
+				exported.DeletedBy = name(item.DeletedBy)
+			}
+		} else {
+			position++
+			exported.Position = position
+		}
+		export.Items = append(export.Items, exported)
+	}
+	return export
+}
+
+// encodeExport writes the export in one of exportFormats
+func encodeExport(ctx context.Context, format string, export ExportedList) ([]byte, error) {
+	switch format {
+	case "txt":
+		return exportText(ctx, export, false), nil
+	case "md":
+		return exportText(ctx, export, true), nil
+	case "csv":
+		return exportCSV(export)
+	case "json":
+		data, err := json.MarshalIndent(export, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode list '%s' as JSON: %w", export.Name, err)
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+// exportText writes the list as numbered lines, or as a Markdown checklist, in the locale of ctx
+func exportText(ctx context.Context, export ExportedList, markdown bool) []byte {
+	var buf bytes.Buffer
+	if markdown {
+		buf.WriteString("# ")
+	}
+	buf.WriteString(export.Name + "\n\n")
+
+	var deleted []ExportedItem
+	for _, item := range export.Items {
+		switch {
+		case item.DeletedAt != nil:
+			deleted = append(deleted, item)
+		case markdown && item.Done:
+			fmt.Fprintf(&buf, "- [x] %s — _%s_\n", item.Label(), item.Author)
+		case markdown:
+			fmt.Fprintf(&buf, "- [ ] %s — _%s_\n", item.Label(), item.Author)
+		case item.Done:
+			fmt.Fprintf(&buf, "%d. ✅ %s — %s\n", item.Position, item.Label(), item.Author)
+		default:
+			fmt.Fprintf(&buf, "%d. %s — %s\n", item.Position, item.Label(), item.Author)
+		}
+	}
+
+	if len(deleted) == 0 {
+		return buf.Bytes()
+	}
+
+	buf.WriteString("\n")
+	if markdown {
+		buf.WriteString("## ")
+	}
+	buf.WriteString(tr(ctx, MsgExportDeleted) + "\n\n")
+	for _, item := range deleted {
+		who := item.Author
+		if item.DeletedBy != "" {
+			who += ", " + tr(ctx, MsgExportDeletedBy, item.DeletedBy, item.DeletedAt.Format(timeLayout))
+		}
+		if markdown {
+			fmt.Fprintf(&buf, "- ~~%s~~ — _%s_\n", item.Label(), who)
+		} else {
+			fmt.Fprintf(&buf, "— %s — %s\n", item.Label(), who)
+		}
+	}
+	return buf.Bytes()
+}
+
+func exportCSV(export ExportedList) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(csvHeader); err != nil {
+		return nil, fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	for _, item := range export.Items {
+		position, quantity, deletedAt, deletedByID := "", "", "", ""
+		if item.Position != 0 {
+			position = strconv.Itoa(item.Position)
+		}
+		if item.Quantity != 0 {
+			quantity = formatQuantity(item.Quantity)
+		}
+		if item.DeletedAt != nil {
+			deletedAt = item.DeletedAt.Format(time.RFC3339)
+		}
+		if item.DeletedByID != 0 {
+			deletedByID = strconv.FormatInt(item.DeletedByID, 10)
+		}
+		record := []string{
+			position,
+			item.Name,
+			quantity,
+			item.Unit,
+			strconv.FormatBool(item.Done),
+			strconv.FormatInt(item.AuthorID, 10),
+			item.Author,
+			item.AddedAt.Format(time.RFC3339),
+			deletedAt,
+			deletedByID,
+			item.DeletedBy,
+		}
+		if err := w.Write(record); err != nil {
+			return nil, fmt.Errorf("failed to write item '%s' as CSV: %w", item.Name, err)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// exportFilename turns the list name into a file name that works on any system
+func exportFilename(listName string, format string) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(listName))
+	if name == "" {
+		name = "list"
+	}
+	return name + "." + format
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// exportList encodes the list's items in one of exportFormats, returning how many items went in
+func (a *app) exportList(ctx context.Context, b *bot.Bot, userID int64, list List, format string, withDeleted bool) ([]byte, int, error) {
+	items, err := a.store.ExportItems(ctx, userID, list.ID, withDeleted)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	data, err := encodeExport(ctx, format, buildExport(ctx, b, list, items))
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, len(items), nil
+}
+
+// exportHandler sends the active list as a file: /export [txt|md|csv|json] [deleted]
+func (a *app) exportHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.Message == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	words := strings.Fields(update.Message.Text)
+	if words[0] != "/export" {
+		sendMessage(ctx, b, userID, tr(ctx, ErrUnknownCommand))
+		return
+	}
+
+	format, withDeleted := exportFormats[0], false
+	for _, word := range words[1:] {
+		word = strings.ToLower(word)
+		switch {
+		case isExportFormat(word):
+			format = word
+		case word == "deleted":
+			withDeleted = true
+		default:
+			sendMessage(ctx, b, userID, tr(ctx, ErrExportArgs))
+			return
+		}
+	}
+
+	list, err := a.store.GetSelectedList(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
+		return
+	}
+
+	data, count, err := a.exportList(ctx, b, userID, list, format, withDeleted)
+	if err != nil {
+		errorLog.Printf("Failed to export list %d as %s for user %d: %v", list.ID, format, userID, err)
+		sendStoreError(ctx, b, userID, err, ErrExportList)
+		return
+	}
+
+	if _, err := b.SendDocument(ctx, &bot.SendDocumentParams{
+		ChatID:   userID,
+		Document: &models.InputFileUpload{Filename: exportFilename(list.Name, format), Data: bytes.NewReader(data)},
+		Caption:  tr(ctx, MsgExported, list.Name, count),
+	}); err != nil {
+		errorLog.Printf("Failed to send export of list %d to %d: %v", list.ID, userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrExportList))
+	}
+}
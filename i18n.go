@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// msgKey names a user-facing text in the catalogs
+type msgKey string
+
+// locale is a language the bot speaks
+type locale string
+
+const (
+	localeRu locale = "ru"
+	localeEn locale = "en"
+)
+
+// defaultLocale is used for users whose language is unknown, the bot started out in Russian
+const defaultLocale = localeRu
+
+// catalogs holds the texts of every locale. Each must have every key of messagesRu
+// with the same format verbs, checkCatalogs makes sure of that in the tests and on start.
+var catalogs = map[locale]map[msgKey]string{
+	localeRu: messagesRu,
+	localeEn: messagesEn,
+}
+
+// localeKey is the context key of the locale of the user being answered
+type localeKey struct{}
+
+func withLocale(ctx context.Context, loc locale) context.Context {
+	return context.WithValue(ctx, localeKey{}, loc)
+}
+
+func localeFrom(ctx context.Context) locale {
+	if loc, ok := ctx.Value(localeKey{}).(locale); ok {
+		return loc
+	}
+	return defaultLocale
+}
+
+// tr returns the text of key in the locale of ctx, formatted with args if there are any
+func tr(ctx context.Context, key msgKey, args ...interface{}) string {
+	text, ok := catalogs[localeFrom(ctx)][key]
+	if !ok {
+		errorLog.Printf("Message %s is missing in locale %s", key, localeFrom(ctx))
+		text = messagesRu[key]
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// parseLocale reads a locale chosen with /lang
+func parseLocale(word string) (locale, bool) {
+	switch strings.ToLower(word) {
+	case "ru", "рус", "русский", "russian":
+		return localeRu, true
+	case "en", "eng", "английский", "english":
+		return localeEn, true
+	}
+	return "", false
+}
+
+// languageLocale picks the locale for a Telegram language_code such as "ru" or "en-US".
+// Russian speakers get Russian, everyone else English, and the default when it's unknown.
+func languageLocale(code string) locale {
+	switch {
+	case code == "":
+		return defaultLocale
+	case strings.HasPrefix(strings.ToLower(code), "ru"):
+		return localeRu
+	default:
+		return localeEn
+	}
+}
+
+// userLocale is the locale chosen with /lang, or the one of the user's Telegram language
+func userLocale(settings Settings, languageCode string) locale {
+	if _, ok := catalogs[locale(settings.Locale)]; ok {
+		return locale(settings.Locale)
+	}
+	if languageCode == "" {
+		languageCode = settings.LanguageCode
+	}
+	return languageLocale(languageCode)
+}
+
+// updateSender returns who sent the update, nil for updates without a sender
+func updateSender(update *models.Update) *models.User {
+	switch {
+	case update.Message != nil:
+		return update.Message.From
+	case update.CallbackQuery != nil:
+		return &update.CallbackQuery.Sender
+	}
+	return nil
+}
+
+// localeMiddleware answers every update in the sender's locale. The Telegram language
+// is remembered, so messages the user gets from others' actions use it too.
+func (a *app) localeMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		userID, err := getUserID(update)
+		if err != nil {
+			next(ctx, b, update)
+			return
+		}
+
+		settings, err := a.store.GetSettings(ctx, userID)
+		if err != nil {
+			errorLog.Printf("Failed to get settings of user %d: %v", userID, err)
+			next(ctx, b, update)
+			return
+		}
+
+		var languageCode string
+		if from := updateSender(update); from != nil {
+			languageCode = from.LanguageCode
+		}
+		if languageCode != "" && languageCode != settings.LanguageCode {
+			if err := a.store.SetLanguageCode(ctx, userID, languageCode); err != nil {
+				errorLog.Printf("Failed to save language of user %d: %v", userID, err)
+			}
+		}
+
+		next(withLocale(ctx, userLocale(settings, languageCode)), b, update)
+	}
+}
+
+// userContext returns ctx in the locale of userID, for messages to someone other than
+// the user whose update is handled, or sent in the background
+func userContext(ctx context.Context, store SettingsRepository, userID int64) context.Context {
+	settings, err := store.GetSettings(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get settings of user %d: %v", userID, err)
+		return withLocale(ctx, defaultLocale)
+	}
+	return withLocale(ctx, userLocale(settings, ""))
+}
+
+// formatVerbs matches the fmt verbs of a text, "%s", "%d", "%[1]s"
+var formatVerbs = regexp.MustCompile(`%(?:\[\d+\])?[-+# 0]*\d*(?:\.\d+)?[a-zA-Z%]`)
+
+// checkCatalogs reports keys missing from a locale, texts left empty and texts whose
+// format verbs differ from the Russian ones, which would garble the message
+func checkCatalogs() error {
+	var problems []string
+	for loc, catalog := range catalogs {
+		for key, ru := range messagesRu {
+			text, ok := catalog[key]
+			switch {
+			case !ok:
+				problems = append(problems, fmt.Sprintf("%s is missing in locale %s", key, loc))
+			case strings.TrimSpace(text) == "":
+				problems = append(problems, fmt.Sprintf("%s is empty in locale %s", key, loc))
+			case strings.Join(formatVerbs.FindAllString(text, -1), " ") != strings.Join(formatVerbs.FindAllString(ru, -1), " "):
+				problems = append(problems, fmt.Sprintf("%s in locale %s has format verbs %q, Russian has %q",
+					key, loc, formatVerbs.FindAllString(text, -1), formatVerbs.FindAllString(ru, -1)))
+			}
+		}
+		for key := range catalog {
+			if _, ok := messagesRu[key]; !ok {
+				problems = append(problems, fmt.Sprintf("%s in locale %s is missing in locale %s", key, loc, localeRu))
+			}
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("message catalogs are incomplete:\n%s", strings.Join(problems, "\n"))
+}
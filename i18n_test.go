@@ -0,0 +1,88 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCheckCatalogs(t *testing.T) {
+	if err := checkCatalogs(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestMessageKeys makes sure every declared msgKey has a text in every locale with the
+// same format verbs, including keys that were added to none of the catalogs
+func TestMessageKeys(t *testing.T) {
+	keys := declaredMessageKeys(t)
+	if len(keys) == 0 {
+		t.Fatal("no msgKey constants found")
+	}
+
+	for _, key := range keys {
+		ru, ok := messagesRu[key]
+		if !ok {
+			t.Errorf("%s is missing in locale %s", key, localeRu)
+			continue
+		}
+		for loc, catalog := range catalogs {
+			text, ok := catalog[key]
+			if !ok {
+				t.Errorf("%s is missing in locale %s", key, loc)
+				continue
+			}
+			if got, want := formatVerbs.FindAllString(text, -1), formatVerbs.FindAllString(ru, -1); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s in locale %s has format verbs %q, Russian has %q", key, loc, got, want)
+			}
+		}
+	}
+}
+
+// declaredMessageKeys reads the msgKey constants from the package source
+func declaredMessageKeys(t *testing.T) []msgKey {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []msgKey
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.CONST {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					value := spec.(*ast.ValueSpec)
+					if typ, ok := value.Type.(*ast.Ident); !ok || typ.Name != "msgKey" {
+						continue
+					}
+					for _, v := range value.Values {
+						lit, ok := v.(*ast.BasicLit)
+						if !ok || lit.Kind != token.STRING {
+							continue
+						}
+						key, err := strconv.Unquote(lit.Value)
+						if err != nil {
+							t.Fatal(err)
+						}
+						keys = append(keys, msgKey(key))
+					}
+				}
+			}
+		}
+	}
+	return keys
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// isDocument matches messages with a file attached
+func isDocument(update *models.Update) bool {
+	return update.Message != nil && update.Message.Document != nil
+}
+
+// downloadFile fetches a file sent to the bot, refusing files larger than maxSize
+func downloadFile(ctx context.Context, b *bot.Bot, fileID string, maxSize int64) ([]byte, error) {
+	file, err := b.GetFile(ctx, &bot.GetFileParams{FileID: fileID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file %s: %w", fileID, err)
+	}
+	if file.FileSize > maxSize {
+		return nil, fmt.Errorf("file %s is %d bytes, at most %d are allowed", fileID, file.FileSize, maxSize)
+	}
+
+	url := "https://api.telegram.org/file/bot" + os.Getenv("MISTER_LISTER_TOKEN") + "/" + file.FilePath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for file %s: %w", fileID, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// The error contains the URL with the token
+		return nil, fmt.Errorf("failed to download file %s", fileID)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download file %s: status %d", fileID, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", fileID, err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("file %s is larger than %d bytes", fileID, maxSize)
+	}
+	return data, nil
+}
+
+// importHandler reads an uploaded file and asks whether to put its items into
+// a new list or the active one
+func (a *app) importHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	doc := update.Message.Document
+	if doc.FileSize > maxImportSize {
+		sendMessage(ctx, b, userID, tr(ctx, ErrImportTooLarge))
+		return
+	}
+
+	data, err := downloadFile(ctx, b, doc.FileID, maxImportSize)
+	if err != nil {
+		errorLog.Printf("Failed to download import of user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrImportFile))
+		return
+	}
+
+	imported, err := parseImport(doc.FileName, data)
+	if err != nil {
+		errorLog.Printf("Failed to parse import '%s' of user %d: %v", doc.FileName, userID, err)
+		if errors.Is(err, errUnsupportedImport) {
+			sendMessage(ctx, b, userID, tr(ctx, ErrImportFormat))
+			return
+		}
+		sendMessage(ctx, b, userID, tr(ctx, ErrImportFile))
+		return
+	}
+	if len(imported.Items) == 0 {
+		sendMessage(ctx, b, userID, tr(ctx, ErrImportEmpty))
+		return
+	}
+	if imported.Title == "" {
+		imported.Title = tr(ctx, MsgImportTitle)
+	}
+
+	var activeID int64
+	lines := []string{tr(ctx, MsgImportPreview, doc.FileName, len(imported.Items))}
+
+	// Only offer the active list to those who may add items to it
+	var active List
+	var duplicates []ListItem
+	if list, err := a.store.GetSelectedList(ctx, userID); err == nil {
+		if err := a.store.CheckRole(ctx, userID, list.ID, RoleEditor); err == nil {
+			existing, err := a.store.ListItems(ctx, list.ID)
+			if err != nil {
+				errorLog.Printf("Failed to get items of list %d for user %d: %v", list.ID, userID, err)
+				sendMessage(ctx, b, userID, tr(ctx, ErrImportFile))
+				return
+			}
+			active, activeID = list, list.ID
+			_, duplicates = splitImportDuplicates(imported.Items, existing)
+		}
+	}
+	if len(duplicates) > 0 {
+		lines = append(lines, tr(ctx, MsgImportDuplicates, active.Name, len(duplicates)))
+	}
+
+	token, err := a.store.SavePendingImport(ctx, userID, activeID, imported)
+	if err != nil {
+		errorLog.Printf("Failed to save import of user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrImportFile))
+		return
+	}
+	kb := &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+		{{Text: tr(ctx, BtnImportNew, imported.Title), CallbackData: fmt.Sprintf("importTo_%s_new", token)}},
+	}}
+	if activeID != 0 {
+		if len(duplicates) > 0 {
+			kb.InlineKeyboard = append(kb.InlineKeyboard, []models.InlineKeyboardButton{
+				{Text: tr(ctx, BtnImportUnique, active.Name), CallbackData: fmt.Sprintf("importTo_%s_active", token)},
+				{Text: tr(ctx, BtnImportAll), CallbackData: fmt.Sprintf("importTo_%s_all", token)},
+			})
+		} else {
+			kb.InlineKeyboard = append(kb.InlineKeyboard, []models.InlineKeyboardButton{
+				{Text: tr(ctx, BtnImportActive, active.Name), CallbackData: fmt.Sprintf("importTo_%s_active", token)},
+			})
+		}
+	}
+	kb.InlineKeyboard = append(kb.InlineKeyboard, []models.InlineKeyboardButton{
+		{Text: tr(ctx, BtnCancel), CallbackData: fmt.Sprintf("importCancel_%s", token)},
+	})
+
+	sendInlineKeyboard(ctx, b, userID, strings.Join(lines, "\n"), kb)
+}
+
+// onImportConfirm writes the previewed items: importTo_<token>_<new|active|all>
+func (a *app) onImportConfirm(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if err := answerCallback(ctx, b, update); err != nil {
+		return
+	}
+
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.CallbackQuery == nil || update.CallbackQuery.Message == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	parts := strings.Split(update.CallbackQuery.Data, "_")
+	if len(parts) != 3 {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	activeID, imported, err := a.store.TakePendingImport(ctx, userID, parts[1])
+	if errors.Is(err, ErrNoPendingImport) {
+		editMessage(ctx, b, update.CallbackQuery.Message, tr(ctx, ErrImportExpired))
+		return
+	}
+	if err != nil {
+		errorLog.Printf("Failed to get import of user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrImportList))
+		return
+	}
+
+	var list List
+	var count int
+	switch parts[2] {
+	case "new":
+		lists, err := a.store.UserLists(ctx, userID)
+		if err != nil {
+			errorLog.Printf("Failed to get lists of user %d: %v", userID, err)
+			sendMessage(ctx, b, userID, tr(ctx, ErrImportList))
+			return
+		}
+		list, err = a.store.ImportList(ctx, userID, uniqueListName(lists, imported.Title), imported.Items)
+		if err != nil {
+			errorLog.Printf("Failed to import %d items into a new list for user %d: %v", len(imported.Items), userID, err)
+			sendMessage(ctx, b, userID, tr(ctx, ErrCreateList))
+			return
+		}
+		count = len(imported.Items)
+	case "active", "all":
+		if activeID == 0 {
+			sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+			return
+		}
+		list, err = a.store.GetList(ctx, activeID)
+		if err != nil {
+			errorLog.Printf("Failed to get list %d for import of user %d: %v", activeID, userID, err)
+			sendMessage(ctx, b, userID, tr(ctx, ErrImportList))
+			return
+		}
+		items := imported.Items
+		if parts[2] == "active" {
+			// The list may have changed since the preview
+			existing, err := a.store.ListItems(ctx, list.ID)
+			if err != nil {
+				errorLog.Printf("Failed to get items of list %d for user %d: %v", list.ID, userID, err)
+				sendMessage(ctx, b, userID, tr(ctx, ErrImportList))
+				return
+			}
+			items, _ = splitImportDuplicates(items, existing)
+		}
+		if len(items) == 0 {
+			editMessage(ctx, b, update.CallbackQuery.Message, tr(ctx, MsgImported, 0, list.Name))
+			return
+		}
+
+		added, err := a.store.AddItems(ctx, userID, list.ID, items)
+		if err != nil {
+			errorLog.Printf("Failed to import %d items into list %d for user %d: %v", len(items), list.ID, userID, err)
+			sendStoreError(ctx, b, userID, err, ErrImportList)
+			return
+		}
+		for _, item := range added {
+			a.notifier.ItemAdded(userID, item)
+		}
+		a.refresher.ListChanged(list.ID)
+		count = len(added)
+	default:
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	editMessage(ctx, b, update.CallbackQuery.Message, tr(ctx, MsgImported, count, list.Name))
+	if err := a.store.SelectList(ctx, userID, list.ID); err != nil {
+		errorLog.Printf("Failed to select list %d for user %d: %v", list.ID, userID, err)
+		return
+	}
+	a.showList(ctx, b, &models.Update{}, userID, list)
+}
+
+func (a *app) onImportCancel(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if err := answerCallback(ctx, b, update); err != nil {
+		return
+	}
+
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.CallbackQuery == nil || update.CallbackQuery.Message == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	token := strings.TrimPrefix(update.CallbackQuery.Data, "importCancel_")
+	if _, _, err := a.store.TakePendingImport(ctx, userID, token); err != nil && !errors.Is(err, ErrNoPendingImport) {
+		errorLog.Printf("Failed to drop import of user %d: %v", userID, err)
+	}
+	editMessage(ctx, b, update.CallbackQuery.Message, tr(ctx, MsgImportCancelled))
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxImportSize  = 1 << 20
+	maxImportItems = 500
+)
+
+var errUnsupportedImport = errors.New("unsupported file format")
+
+var (
+	// checkboxPrefix matches markdown checkboxes, "- [x] " marks a checked off item
+	checkboxPrefix = regexp.MustCompile(`^(?:[-*+]\s+)?\[([ xX])\]\s*`)
+	// mdAuthorSuffix is the " — _author_" the Markdown export puts after every item
+	mdAuthorSuffix = regexp.MustCompile(`\s+—\s+_[^_]*_$`)
+	// txtAuthorSuffix is the " — author" the text export puts after every item
+	txtAuthorSuffix = regexp.MustCompile(`\s+—\s+[^—]+$`)
+)
+
+// importedList is what an uploaded file turned into
+type importedList struct {
+	Title string
+	Items []ListItem
+}
+
+// parseImport reads a file in one of exportFormats, telling them apart by extension.
+// Besides our own exports it understands plain lines of text, markdown checklists
+// and CSV files with a "name" column. The title is left empty when neither the file
+// nor its name gives one.
+func parseImport(filename string, data []byte) (importedList, error) {
+	if !utf8.Valid(data) {
+		return importedList{}, fmt.Errorf("%s is not UTF-8 text: %w", filename, errUnsupportedImport)
+	}
+	data = bytes.TrimPrefix(data, []byte("\uFEFF"))
+
+	ext := strings.ToLower(path.Ext(filename))
+	var imported importedList
+	var err error
+	switch ext {
+	case ".txt", "":
+		imported = parseImportText(string(data), false)
+	case ".md", ".markdown":
+		imported = parseImportText(string(data), true)
+	case ".csv":
+		imported, err = parseImportCSV(data)
+	case ".json":
+		imported, err = parseImportJSON(data)
+	default:
+		return importedList{}, fmt.Errorf("%s: %w", filename, errUnsupportedImport)
+	}
+	if err != nil {
+		return importedList{}, err
+	}
+
+	if imported.Title == "" {
+		imported.Title = strings.TrimSpace(strings.TrimSuffix(path.Base(filename), path.Ext(filename)))
+	}
+	if imported.Title == "." {
+		imported.Title = ""
+	}
+	imported.Items = mergeImported(imported.Items)
+	if len(imported.Items) > maxImportItems {
+		return importedList{}, fmt.Errorf("%s has %d items, at most %d can be imported", filename, len(imported.Items), maxImportItems)
+	}
+	return imported, nil
+}
+
+// isDeletedHeading reports whether the line starts the deleted section of an export in any locale
+func isDeletedHeading(line string) bool {
+	for _, catalog := range catalogs {
+		if line == catalog[MsgExportDeleted] {
+			return true
+		}
+	}
+	return false
+}
+
+// parseImportText reads an item per line. A text export starts with the list name
+// followed by an empty line, a Markdown one with a "# " heading; items from the
+// deleted section of either are skipped.
+func parseImportText(text string, markdown bool) importedList {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	lines := strings.Split(text, "\n")
+
+	var imported importedList
+	exported := false
+	if !markdown && len(lines) > 2 && strings.TrimSpace(lines[1]) == "" && strings.HasPrefix(lines[2], "1. ") {
+		imported.Title = strings.TrimSpace(lines[0])
+		lines = lines[2:]
+		exported = true
+	}
+	// Like a message, a single line is split on commas
+	if !markdown && !exported && len(lines) == 1 {
+		lines = splitCommas(lines[0])
+	}
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if markdown && strings.HasPrefix(line, "#") {
+			heading := strings.TrimSpace(strings.TrimLeft(line, "#"))
+			if strings.HasPrefix(line, "# ") && imported.Title == "" {
+				imported.Title = heading
+			}
+			if isDeletedHeading(heading) {
+				break
+			}
+			continue
+		}
+		if exported && isDeletedHeading(line) {
+			break
+		}
+
+		done := false
+		if m := checkboxPrefix.FindStringSubmatch(line); m != nil {
+			done = m[1] != " "
+			line = line[len(m[0]):]
+		}
+		line = strings.TrimSpace(bulletPrefix.ReplaceAllString(line, ""))
+		if rest, ok := strings.CutPrefix(line, "✅"); ok {
+			done = true
+			line = strings.TrimSpace(rest)
+		}
+
+		if markdown {
+			line = mdAuthorSuffix.ReplaceAllString(line, "")
+		} else if exported {
+			line = txtAuthorSuffix.ReplaceAllString(line, "")
+		}
+		if line == "" {
+			continue
+		}
+
+		item := parseItem(line)
+		item.Done = done
+		imported.Items = append(imported.Items, item)
+	}
+	return imported
+}
+
+// parseImportCSV reads a CSV file with a header. Items are taken from the "name" column,
+// or the first one, along with "quantity", "unit" and "done" if the file has them.
+// Rows with a "deleted_at" are deleted items of an export and are skipped.
+func parseImportCSV(data []byte) (importedList, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	header, err := r.Read()
+	if err != nil {
+		return importedList{}, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	field := func(record []string, name string) (string, bool) {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return "", false
+		}
+		return strings.TrimSpace(record[i]), true
+	}
+	nameColumn := "name"
+	if _, ok := columns[nameColumn]; !ok {
+		nameColumn = strings.ToLower(strings.TrimSpace(header[0]))
+	}
+
+	var imported importedList
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return importedList{}, fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		name, _ := field(record, nameColumn)
+		if name == "" {
+			continue
+		}
+		if deletedAt, _ := field(record, "deleted_at"); deletedAt != "" {
+			continue
+		}
+
+		var item ListItem
+		if quantity, ok := field(record, "quantity"); ok {
+			item = ListItem{Name: name}
+			if quantity != "" {
+				q, err := strconv.ParseFloat(strings.Replace(quantity, ",", ".", 1), 64)
+				if err != nil || q < 0 {
+					return importedList{}, fmt.Errorf("invalid quantity %q of item '%s'", quantity, name)
+				}
+				item.Quantity = q
+			}
+			if item.Quantity != 0 {
+				item.Unit, _ = field(record, "unit")
+				item.Unit = strings.ToLower(item.Unit)
+			}
+		} else {
+			item = parseItem(name)
+		}
+		if done, _ := field(record, "done"); done != "" {
+			item.Done, _ = strconv.ParseBool(strings.ToLower(done))
+		}
+		imported.Items = append(imported.Items, item)
+	}
+	return imported, nil
+}
+
+// parseImportJSON reads a file written by the JSON export, keeping the items' positions
+func parseImportJSON(data []byte) (importedList, error) {
+	var export ExportedList
+	if err := json.Unmarshal(data, &export); err != nil {
+		return importedList{}, fmt.Errorf("failed to decode JSON export: %w", err)
+	}
+
+	var items []ExportedItem
+	for _, item := range export.Items {
+		if item.DeletedAt == nil && strings.TrimSpace(item.Name) != "" {
+			items = append(items, item)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Position < items[j].Position })
+
+	imported := importedList{Title: strings.TrimSpace(export.Name)}
+	for _, item := range items {
+		imported.Items = append(imported.Items, ListItem{
+			Name:     strings.TrimSpace(item.Name),
+			Quantity: item.Quantity,
+			Unit:     item.Unit,
+			Done:     item.Done,
+		})
+	}
+	return imported, nil
+}
+
+// mergeImported adds up items repeated within the file, the way the "Объединить" button
+// would. Items with different units, or checked off on one line only, stay apart.
+func mergeImported(items []ListItem) []ListItem {
+	var merged []ListItem
+	for _, item := range items {
+		found := false
+		for i := range merged {
+			if normalizeName(merged[i].Name) == normalizeName(item.Name) &&
+				merged[i].Unit == item.Unit && merged[i].Done == item.Done {
+				merged[i].Quantity = merged[i].count() + item.count()
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, item)
+		}
+	}
+	return merged
+}
+
+// splitImportDuplicates separates the imported items that the list already has, checked off items don't count
+func splitImportDuplicates(items []ListItem, existing []ListItem) (fresh []ListItem, duplicates []ListItem) {
+	names := make(map[string]bool)
+	for _, item := range existing {
+		if !item.Done {
+			names[normalizeName(item.Name)] = true
+		}
+	}
+	for _, item := range items {
+		if names[normalizeName(item.Name)] {
+			duplicates = append(duplicates, item)
+		} else {
+			fresh = append(fresh, item)
+		}
+	}
+	return fresh, duplicates
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	defaultInviteTTL = 7 * 24 * time.Hour
+	maxInviteTTL     = 90 * 24 * time.Hour
+)
+
+// parseInviteTTL parses durations such as "12h" or "7d"
+func parseInviteTTL(s string) (time.Duration, bool) {
+	if len(s) < 2 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+
+	var ttl time.Duration
+	switch s[len(s)-1] {
+	case 'h':
+		ttl = time.Duration(n) * time.Hour
+	case 'd':
+		ttl = time.Duration(n) * 24 * time.Hour
+	default:
+		return 0, false
+	}
+	return ttl, ttl <= maxInviteTTL
+}
+
+func (a *app) inviteLink(token string) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s", a.botUsername, token)
+}
+
+func (a *app) inviteHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.Message == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	list, err := a.store.GetSelectedList(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
+		return
+	}
+
+	role := RoleEditor
+	maxUses := 1
+	ttl := defaultInviteTTL
+	for _, word := range strings.Fields(update.Message.Text)[1:] {
+		if r, ok := parseRole(word); ok {
+			role = r
+			continue
+		}
+		if d, ok := parseInviteTTL(strings.ToLower(word)); ok {
+			ttl = d
+			continue
+		}
+		switch strings.ToLower(word) {
+		case "multi", "многоразовое":
+			maxUses = 0
+		default:
+			sendMessage(ctx, b, userID, tr(ctx, ErrInviteArgs))
+			return
+		}
+	}
+
+	invite, err := a.store.CreateInvite(ctx, userID, list.ID, role, maxUses, ttl)
+	if err != nil {
+		errorLog.Printf("Failed to create invite to list %d for user %d: %v", list.ID, userID, err)
+		sendStoreError(ctx, b, userID, err, ErrCreateInvite)
+		return
+	}
+
+	uses := tr(ctx, MsgInviteSingleUse)
+	if invite.MaxUses == 0 {
+		uses = tr(ctx, MsgInviteMultiUse)
+	}
+	sendMessage(ctx, b, userID, tr(ctx, MsgInviteCreated, list.Name, roleTitle(ctx, invite.Role), uses, invite.ExpiresAt.Format(timeLayout)))
+	sendPlainMessage(ctx, b, userID, a.inviteLink(invite.Token))
+}
+
+func (a *app) invitesHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	list, err := a.store.GetSelectedList(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
+		return
+	}
+
+	invites, err := a.store.ListInvites(ctx, userID, list.ID)
+	if err != nil {
+		errorLog.Printf("Failed to get invites to list %d for user %d: %v", list.ID, userID, err)
+		sendStoreError(ctx, b, userID, err, ErrListInvites)
+		return
+	}
+
+	if len(invites) == 0 {
+		sendMessage(ctx, b, userID, tr(ctx, MsgNoInvites))
+		return
+	}
+
+	lines := []string{tr(ctx, MsgInvites, list.Name)}
+	kb := &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{}}
+	for i, invite := range invites {
+		uses := fmt.Sprintf("%d/%d", invite.Uses, invite.MaxUses)
+		if invite.MaxUses == 0 {
+			uses = fmt.Sprintf("%d/∞", invite.Uses)
+		}
+		lines = append(lines, tr(ctx, MsgInviteLine, i+1, roleTitle(ctx, invite.Role), uses, invite.ExpiresAt.Format(timeLayout)))
+		kb.InlineKeyboard = append(kb.InlineKeyboard, []models.InlineKeyboardButton{
+			{Text: tr(ctx, BtnRevokeInvite, i+1), CallbackData: fmt.Sprintf("revokeInvite_%d", invite.ID)},
+		})
+	}
+
+	sendInlineKeyboard(ctx, b, userID, strings.Join(lines, "\n"), kb)
+}
+
+func (a *app) onInviteRevoke(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if err := answerCallback(ctx, b, update); err != nil {
+		return
+	}
+
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.CallbackQuery == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	idStr, ok := strings.CutPrefix(update.CallbackQuery.Data, "revokeInvite_")
+	if !ok {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	inviteID, err := parseInt64(idStr)
+	if err != nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidID))
+		return
+	}
+
+	if err := a.store.RevokeInvite(ctx, userID, inviteID); err != nil {
+		errorLog.Printf("Failed to revoke invite %d for user %d: %v", inviteID, userID, err)
+		sendStoreError(ctx, b, userID, err, ErrRevokeInvite)
+		return
+	}
+
+	sendMessage(ctx, b, userID, tr(ctx, MsgInviteRevoked))
+}
+
+// redeemInvite handles /start <token> deep links
+func (a *app) redeemInvite(ctx context.Context, b *bot.Bot, userID int64, token string) {
+	list, invite, err := a.store.RedeemInvite(ctx, userID, token)
+	if err != nil {
+		errorLog.Printf("Failed to redeem invite for user %d: %v", userID, err)
+		if errors.Is(err, ErrInviteInvalid) {
+			sendMessage(ctx, b, userID, tr(ctx, ErrInviteExpired))
+		} else {
+			sendMessage(ctx, b, userID, tr(ctx, ErrShareList))
+		}
+		return
+	}
+
+	sendMessage(ctx, b, userID, tr(ctx, MsgInviteAccepted, list.Name, roleTitle(ctx, invite.Role)))
+	if invite.CreatedBy != 0 && invite.CreatedBy != userID {
+		creatorCtx := userContext(ctx, a.store, invite.CreatedBy)
+		sendMessage(ctx, b, invite.CreatedBy, tr(creatorCtx, MsgInviteJoined, userDisplayName(ctx, b, userID), list.Name))
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Invite lets whoever has its token join a list with the given role
+type Invite struct {
+	gorm.Model
+	Token     string `gorm:"uniqueIndex;not null"`
+	ListID    int64  `gorm:"index"`
+	CreatedBy int64
+	Role      Role `gorm:"not null"`
+	MaxUses   int  // 0 means unlimited until the invite expires
+	Uses      int
+	ExpiresAt time.Time
+}
+
+// ErrInviteInvalid is returned for unknown, revoked, expired or used up invites
+var ErrInviteInvalid = errors.New("invite is invalid")
+
+// InviteRepository manages invite links to lists
+type InviteRepository interface {
+	CreateInvite(ctx context.Context, userID int64, listID int64, role Role, maxUses int, ttl time.Duration) (Invite, error)
+	ListInvites(ctx context.Context, userID int64, listID int64) ([]Invite, error)
+	RevokeInvite(ctx context.Context, userID int64, inviteID int64) error
+	RedeemInvite(ctx context.Context, userID int64, token string) (List, Invite, error)
+}
+
+// active reports whether the invite can still be redeemed
+func (i Invite) active(now time.Time) bool {
+	return now.Before(i.ExpiresAt) && (i.MaxUses == 0 || i.Uses < i.MaxUses)
+}
+
+// newInviteToken returns a random token that fits into a /start deep link parameter
+func newInviteToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func (s *Store) CreateInvite(ctx context.Context, userID int64, listID int64, role Role, maxUses int, ttl time.Duration) (Invite, error) {
+	if err := s.CheckRole(ctx, userID, listID, RoleOwner); err != nil {
+		return Invite{}, err
+	}
+
+	token, err := newInviteToken()
+	if err != nil {
+		return Invite{}, fmt.Errorf("failed to generate invite token: %w", err)
+	}
+
+	invite := Invite{
+		Token:     token,
+		ListID:    listID,
+		CreatedBy: userID,
+		Role:      role,
+		MaxUses:   maxUses,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.db.WithContext(ctx).Create(&invite).Error; err != nil {
+		return Invite{}, fmt.Errorf("failed to create invite to list %d for user %d: %w", listID, userID, err)
+	}
+	return invite, nil
+}
+
+// ListInvites returns the invites to the list that can still be redeemed
+func (s *Store) ListInvites(ctx context.Context, userID int64, listID int64) ([]Invite, error) {
+	if err := s.CheckRole(ctx, userID, listID, RoleOwner); err != nil {
+		return nil, err
+	}
+
+	var invites []Invite
+	if err := s.db.WithContext(ctx).
+		Where("list_id = ? AND expires_at > ? AND (max_uses = 0 OR uses < max_uses)", listID, time.Now()).
+		Order("id ASC").
+		Find(&invites).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch invites to list %d: %w", listID, err)
+	}
+	return invites, nil
+}
+
+func (s *Store) RevokeInvite(ctx context.Context, userID int64, inviteID int64) error {
+	var invite Invite
+	if err := s.db.WithContext(ctx).First(&invite, "id = ?", inviteID).Error; err != nil {
+		return fmt.Errorf("failed to fetch invite %d: %w", inviteID, err)
+	}
+
+	if err := s.CheckRole(ctx, userID, invite.ListID, RoleOwner); err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Delete(&invite).Error; err != nil {
+		return fmt.Errorf("failed to revoke invite %d: %w", inviteID, err)
+	}
+	return nil
+}
+
+// RedeemInvite adds the user to the invite's list and makes it their active list.
+// Members of the list keep their role and don't use up the invite, for them
+// only the role of the returned invite is set.
+func (s *Store) RedeemInvite(ctx context.Context, userID int64, token string) (List, Invite, error) {
+	var invite Invite
+	if err := s.db.WithContext(ctx).First(&invite, "token = ?", token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return List{}, Invite{}, ErrInviteInvalid
+		}
+		return List{}, Invite{}, fmt.Errorf("failed to fetch invite: %w", err)
+	}
+
+	list, err := s.GetList(ctx, invite.ListID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return List{}, Invite{}, ErrInviteInvalid
+		}
+		return List{}, Invite{}, err
+	}
+
+	if role, err := s.GetRole(ctx, userID, invite.ListID); err == nil {
+		return list, Invite{Role: role}, s.SelectList(ctx, userID, invite.ListID)
+	}
+
+	if !invite.active(time.Now()) {
+		return List{}, Invite{}, ErrInviteInvalid
+	}
+
+	// Claim a use first, so two people can't redeem the last use at once
+	result := s.db.WithContext(ctx).Model(&Invite{}).
+		Where("id = ? AND (max_uses = 0 OR uses < max_uses)", invite.ID).
+		Update("uses", gorm.Expr("uses + 1"))
+	if result.Error != nil {
+		return List{}, Invite{}, fmt.Errorf("failed to use invite %d: %w", invite.ID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return List{}, Invite{}, ErrInviteInvalid
+	}
+
+	if err := s.AddOwner(ctx, invite.CreatedBy, invite.ListID, userID, invite.Role); err != nil {
+		s.db.WithContext(ctx).Model(&Invite{}).
+			Where("id = ?", invite.ID).
+			Update("uses", gorm.Expr("uses - 1"))
+		if errors.Is(err, ErrForbidden) || errors.Is(err, gorm.ErrRecordNotFound) {
+			// The inviter is no longer an owner of the list
+			return List{}, Invite{}, ErrInviteInvalid
+		}
+		return List{}, Invite{}, err
+	}
+
+	return list, invite, s.SelectList(ctx, userID, invite.ListID)
+}
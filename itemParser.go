@@ -0,0 +1,183 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// bulletPrefix matches list markers people paste along with items:
+// "- ", "* ", "• ", "1. ", "2) " and markdown checkboxes such as "- [ ] "
+var bulletPrefix = regexp.MustCompile(`^(?:[-*–—]\s+|•\s*|\d+[.)]\s+)?(?:\[[ xX]?\]\s*)?`)
+
+const (
+	quantityPattern = `(\d+(?:[.,]\d+)?)`
+	unitPattern     = `((?i:ml|l|kg|g|pcs|pc|pack|мл|л|кг|г|шт|упак|уп))\.?`
+	// "x" or the Cyrillic "х" must stand apart from the name, so "2 хлеба" stays as is
+	timesBefore = `(?:\s*[xх]\s+|\s*×\s*)`
+	timesAfter  = `(?:\s+[xх]\s*|\s*×\s*)`
+)
+
+var (
+	// "3x eggs", "3 × eggs"
+	countPrefix = regexp.MustCompile(`^` + quantityPattern + timesBefore + `(.+)$`)
+	// "eggs x3", "eggs ×3"
+	countSuffix = regexp.MustCompile(`^(.+?)` + timesAfter + quantityPattern + `$`)
+	// "Milk 2l", "мука 200 г"
+	amountSuffix = regexp.MustCompile(`^(.+?)\s+` + quantityPattern + `\s*` + unitPattern + `$`)
+	// "2l milk", "200 г муки"
+	amountPrefix = regexp.MustCompile(`^` + quantityPattern + `\s*` + unitPattern + `\s+(.+)$`)
+)
+
+// parseItems splits a message into items. Every line is an item, so a pasted
+// ingredient list adds one item per line. Users who turned on /commas get a single
+// line split on commas instead, which lets "milk, eggs, bread" be typed in one go,
+// otherwise "cheese, hard" stays one item. Items may end with a due date,
+// "pay rent @friday 18:00", relative days are counted from now.
+func parseItems(text string, now time.Time, splitOnCommas bool) []ListItem {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if len(lines) == 1 && splitOnCommas {
+		lines = splitCommas(lines[0])
+	}
+
+	var items []ListItem
+	for _, line := range lines {
+		line = strings.TrimSpace(bulletPrefix.ReplaceAllString(strings.TrimSpace(line), ""))
+		if line == "" {
+			continue
+		}
+		line, due := parseDue(line, now)
+		item := parseItem(line)
+		item.DueAt = due
+		items = append(items, item)
+	}
+	return items
+}
+
+// splitCommas splits on commas, except decimal commas such as in "1,5 л"
+func splitCommas(s string) []string {
+	runes := []rune(s)
+	var parts []string
+	start := 0
+	for i, r := range runes {
+		if r != ',' {
+			continue
+		}
+		if i > 0 && i+1 < len(runes) && unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1]) {
+			continue
+		}
+		parts = append(parts, string(runes[start:i]))
+		start = i + 1
+	}
+	return append(parts, string(runes[start:]))
+}
+
+// parseItem extracts the quantity and unit from a single item
+func parseItem(text string) ListItem {
+	var name, quantity, unit string
+	if m := countPrefix.FindStringSubmatch(text); m != nil {
+		name, quantity = m[2], m[1]
+	} else if m := countSuffix.FindStringSubmatch(text); m != nil {
+		name, quantity = m[1], m[2]
+	} else if m := amountSuffix.FindStringSubmatch(text); m != nil {
+		name, quantity, unit = m[1], m[2], m[3]
+	} else if m := amountPrefix.FindStringSubmatch(text); m != nil {
+		name, quantity, unit = m[3], m[1], m[2]
+	} else {
+		return ListItem{Name: text}
+	}
+
+	q, err := strconv.ParseFloat(strings.Replace(quantity, ",", ".", 1), 64)
+	if err != nil || q <= 0 || strings.TrimSpace(name) == "" {
+		return ListItem{Name: text}
+	}
+	return ListItem{
+		Name:     strings.TrimSpace(name),
+		Quantity: q,
+		Unit:     strings.ToLower(unit),
+	}
+}
+
+// dueSuffix matches a due date at the end of an item: "@friday", "@25.12 18:00", "@18:00"
+var dueSuffix = regexp.MustCompile(`^(.+?)\s+@(\S+)(?:\s+(\d{1,2}:\d{2}))?$`)
+
+// defaultDueTime is when an item is due if only its day was given
+const defaultDueTime = "09:00"
+
+// parseDue cuts a due date off the end of the item. Days are "today", "tomorrow",
+// weekdays, "25.12", "25.12.2026" or "2026-12-25", in English or Russian, optionally
+// followed by a time. A time alone means its next occurrence. Text without a due
+// date, or with one that doesn't parse, such as an e-mail address, is returned as is.
+func parseDue(text string, now time.Time) (string, *time.Time) {
+	m := dueSuffix.FindStringSubmatch(text)
+	if m == nil {
+		return text, nil
+	}
+	name, day, clock := strings.TrimSpace(m[1]), strings.ToLower(m[2]), m[3]
+
+	if clock == "" {
+		if t, err := time.Parse("15:04", day); err == nil {
+			due := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+			if !due.After(now) {
+				due = due.AddDate(0, 0, 1)
+			}
+			return name, &due
+		}
+		clock = defaultDueTime
+	}
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return text, nil
+	}
+
+	date, ok := parseDueDay(day, now)
+	if !ok {
+		return text, nil
+	}
+	due := time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	// A weekday or a date without a year that already passed means the next one
+	if !due.After(now) {
+		if _, isWeekday := parseWeekday(day); isWeekday {
+			due = due.AddDate(0, 0, 7)
+		} else if _, err := time.Parse("2.1", day); err == nil {
+			due = due.AddDate(1, 0, 0)
+		}
+	}
+	return name, &due
+}
+
+// parseDueDay returns the day the word names, the time of day is left as in now
+func parseDueDay(word string, now time.Time) (time.Time, bool) {
+	switch word {
+	case "today", "сегодня":
+		return now, true
+	case "tomorrow", "завтра":
+		return now.AddDate(0, 0, 1), true
+	case "послезавтра":
+		return now.AddDate(0, 0, 2), true
+	}
+	for _, layout := range []string{"2.1.2006", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, word, now.Location()); err == nil {
+			return t, true
+		}
+	}
+	if t, err := time.Parse("2.1", word); err == nil {
+		return time.Date(now.Year(), t.Month(), t.Day(), 0, 0, 0, 0, now.Location()), true
+	}
+	if day, ok := parseWeekday(word); ok {
+		return now.AddDate(0, 0, (int(day)-int(now.Weekday())+7)%7), true
+	}
+	return time.Time{}, false
+}
+
+// normalizeName makes names that differ only in case and spacing compare equal
+func normalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// formatQuantity prints 2 as "2" and 1.5 as "1.5"
+func formatQuantity(q float64) string {
+	return strconv.FormatFloat(q, 'f', -1, 64)
+}
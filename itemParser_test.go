@@ -0,0 +1,96 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseItems(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name        string
+		text        string
+		splitCommas bool
+		want        []string
+	}{
+		{name: "single item", text: "milk", want: []string{"milk"}},
+		{name: "comma kept by default", text: "Сыр, твёрдый", want: []string{"Сыр, твёрдый"}},
+		{name: "comma in a note", text: "Call Anna, re: keys", want: []string{"Call Anna, re: keys"}},
+		{name: "commas split when on", text: "milk, eggs, bread", splitCommas: true, want: []string{"milk", "eggs", "bread"}},
+		{name: "decimal comma", text: "молоко 1,5 л, хлеб", splitCommas: true, want: []string{"молоко 1.5 л", "хлеб"}},
+		{name: "lines", text: "milk\neggs\r\nbread", want: []string{"milk", "eggs", "bread"}},
+		{name: "lines keep commas", text: "Сыр, твёрдый\nmilk", splitCommas: true, want: []string{"Сыр, твёрдый", "milk"}},
+		{name: "bullets", text: "- milk\n* [ ] eggs\n• bread\n2) butter", want: []string{"milk", "eggs", "bread", "butter"}},
+		{name: "blank lines", text: "milk\n\n  \neggs", want: []string{"milk", "eggs"}},
+		{name: "counts", text: "3x eggs\nbread ×2", want: []string{"eggs ×3", "bread ×2"}},
+		{name: "store name", text: "молоко @пятерочка", want: []string{"молоко @пятерочка"}},
+		{name: "e-mail", text: "write to anna@example.com", want: []string{"write to anna@example.com"}},
+		{name: "nothing", text: " ", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := parseItems(tt.text, now, tt.splitCommas)
+			var got []string
+			for _, item := range items {
+				got = append(got, item.Label())
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseItems(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("parseItems(%q) = %q, want %q", tt.text, got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestParseDue(t *testing.T) {
+	// Friday
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+	at := func(year int, month time.Month, day, hour, minute int) *time.Time {
+		due := time.Date(year, month, day, hour, minute, 0, 0, time.Local)
+		return &due
+	}
+
+	tests := []struct {
+		text string
+		name string
+		due  *time.Time
+	}{
+		{"milk", "milk", nil},
+		{"milk @tomorrow", "milk", at(2026, 10, 17, 9, 0)},
+		{"milk @завтра 18:30", "milk", at(2026, 10, 17, 18, 30)},
+		{"milk @18:00", "milk", at(2026, 10, 16, 18, 0)},
+		{"milk @sat", "milk", at(2026, 10, 17, 9, 0)},
+		{"молоко @субботу 10:00", "молоко", at(2026, 10, 17, 10, 0)},
+		{"молоко @пн", "молоко", at(2026, 10, 19, 9, 0)},
+		{"milk @25.12", "milk", at(2026, 12, 25, 9, 0)},
+		{"milk @2026-11-01 08:15", "milk", at(2026, 11, 1, 8, 15)},
+		// Times that already passed mean the next one
+		{"milk @10:00", "milk", at(2026, 10, 17, 10, 0)},
+		{"milk @friday 11:00", "milk", at(2026, 10, 23, 11, 0)},
+		{"milk @1.10", "milk", at(2027, 10, 1, 9, 0)},
+		// Words that only start like a day are no due date
+		{"молоко @пятерочка", "молоко @пятерочка", nil},
+		{"cable @monitor", "cable @monitor", nil},
+		{"ticket @saturn", "ticket @saturn", nil},
+		{"write to anna@example.com", "write to anna@example.com", nil},
+		{"write to @example.com", "write to @example.com", nil},
+		{"milk @25:00", "milk @25:00", nil},
+	}
+	for _, tt := range tests {
+		name, due := parseDue(tt.text, now)
+		if name != tt.name {
+			t.Errorf("parseDue(%q) name = %q, want %q", tt.text, name, tt.name)
+		}
+		switch {
+		case due == nil && tt.due == nil:
+		case due == nil || tt.due == nil || !due.Equal(*tt.due):
+			t.Errorf("parseDue(%q) due = %v, want %v", tt.text, due, tt.due)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultTrashDays is how long deleted items are kept for lists without their own setting
+	defaultTrashDays = 30
+	maxTrashDays     = 365
+	janitorInterval  = time.Hour
+	// defaultVacuumInterval is used when MISTER_LISTER_VACUUM_INTERVAL isn't set
+	defaultVacuumInterval = 7 * 24 * time.Hour
+)
+
+// PurgedItems is how many deleted items of a list the janitor removed for good
+type PurgedItems struct {
+	ListID int64
+	Count  int64
+	Before time.Time
+}
+
+// JanitorRepository cleans up data nobody can get back anymore
+type JanitorRepository interface {
+	PurgeDeletedItems(ctx context.Context, defaultDays int, now time.Time) ([]PurgedItems, error)
+	Vacuum(ctx context.Context) error
+}
+
+// PurgeDeletedItems hard-deletes items that were deleted more than the list's
+// trash retention ago, defaultDays applies to lists without their own setting
+func (s *Store) PurgeDeletedItems(ctx context.Context, defaultDays int, now time.Time) ([]PurgedItems, error) {
+	var lists []struct {
+		ListID    int64
+		TrashDays int
+	}
+	if err := s.db.WithContext(ctx).Unscoped().Model(&ListItem{}).
+		Select("DISTINCT list_items.list_id, COALESCE(lists.trash_days, 0) AS trash_days").
+		Joins("LEFT JOIN lists ON lists.id = list_items.list_id").
+		Where("list_items.deleted_at IS NOT NULL").
+		Scan(&lists).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch lists with deleted items: %w", err)
+	}
+
+	var purged []PurgedItems
+	for _, list := range lists {
+		days := list.TrashDays
+		if days == 0 {
+			days = defaultDays
+		}
+		before := now.AddDate(0, 0, -days)
+
+		result := s.db.WithContext(ctx).Unscoped().
+			Where("list_id = ? AND deleted_at IS NOT NULL AND deleted_at < ?", list.ListID, before).
+			Delete(&ListItem{})
+		if result.Error != nil {
+			return purged, fmt.Errorf("failed to purge deleted items of list %d: %w", list.ListID, result.Error)
+		}
+		if result.RowsAffected > 0 {
+			purged = append(purged, PurgedItems{ListID: list.ListID, Count: result.RowsAffected, Before: before})
+		}
+	}
+	return purged, nil
+}
+
+// Vacuum gives the space of purged rows back, both SQLite and Postgres understand plain VACUUM
+func (s *Store) Vacuum(ctx context.Context) error {
+	if err := s.db.WithContext(ctx).Exec("VACUUM").Error; err != nil {
+		return fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	return nil
+}
+
+// trashDaysSetting returns how long deleted items are kept by default, set with
+// MISTER_LISTER_TRASH_DAYS. An invalid value is reported and the default is returned.
+func trashDaysSetting() (int, error) {
+	v := os.Getenv("MISTER_LISTER_TRASH_DAYS")
+	if v == "" {
+		return defaultTrashDays, nil
+	}
+	days, err := strconv.Atoi(v)
+	if err != nil || days <= 0 || days > maxTrashDays {
+		return defaultTrashDays, fmt.Errorf("invalid MISTER_LISTER_TRASH_DAYS %q", v)
+	}
+	return days, nil
+}
+
+// runJanitor purges old deleted items every janitorInterval and vacuums the database
+// every MISTER_LISTER_VACUUM_INTERVAL until ctx is done. MISTER_LISTER_TRASH_DAYS
+// sets how long deleted items are kept by default.
+func runJanitor(ctx context.Context, store JanitorRepository) {
+	defaultDays, err := trashDaysSetting()
+	if err != nil {
+		errorLog.Printf("%v, using %d days", err, defaultDays)
+	}
+
+	vacuumInterval := defaultVacuumInterval
+	if v := os.Getenv("MISTER_LISTER_VACUUM_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			errorLog.Printf("Invalid MISTER_LISTER_VACUUM_INTERVAL %q, using %s", v, defaultVacuumInterval)
+		} else {
+			vacuumInterval = d
+		}
+	}
+
+	log.Printf("Janitor started: keeping deleted items for %d days, vacuuming every %s", defaultDays, vacuumInterval)
+
+	purgeTicker := time.NewTicker(janitorInterval)
+	defer purgeTicker.Stop()
+	vacuumTicker := time.NewTicker(vacuumInterval)
+	defer vacuumTicker.Stop()
+
+	purge := func() {
+		purged, err := store.PurgeDeletedItems(ctx, defaultDays, time.Now())
+		for _, p := range purged {
+			log.Printf("Janitor removed %d items of list %d deleted before %s", p.Count, p.ListID, p.Before.Format(timeLayout))
+		}
+		if err != nil {
+			errorLog.Printf("Janitor failed to purge deleted items: %v", err)
+		}
+	}
+
+	purge()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-purgeTicker.C:
+			purge()
+		case <-vacuumTicker.C:
+			start := time.Now()
+			if err := store.Vacuum(ctx); err != nil {
+				errorLog.Printf("Janitor failed to vacuum: %v", err)
+				continue
+			}
+			log.Printf("Janitor vacuumed the database in %s", time.Since(start).Round(time.Millisecond))
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// localeNames are the languages as their speakers call them
+var localeNames = map[locale]string{
+	localeRu: "русский",
+	localeEn: "English",
+}
+
+// langHandler shows or overrides the bot's language: /lang [ru|en|auto]
+func (a *app) langHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.Message == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	words := strings.Fields(update.Message.Text)
+	if words[0] != "/lang" {
+		sendMessage(ctx, b, userID, tr(ctx, ErrUnknownCommand))
+		return
+	}
+
+	if len(words) < 2 {
+		sendMessage(ctx, b, userID, tr(ctx, MsgLang, localeNames[localeFrom(ctx)]))
+		return
+	}
+
+	if strings.ToLower(words[1]) == "auto" {
+		if err := a.store.SetLocale(ctx, userID, ""); err != nil {
+			errorLog.Printf("Failed to reset locale of user %d: %v", userID, err)
+			sendMessage(ctx, b, userID, tr(ctx, ErrSaveSettings))
+			return
+		}
+		ctx = userContext(ctx, a.store, userID)
+		sendMessage(ctx, b, userID, tr(ctx, MsgLangAuto))
+		return
+	}
+
+	loc, ok := parseLocale(words[1])
+	if !ok {
+		sendMessage(ctx, b, userID, tr(ctx, ErrLangArgs))
+		return
+	}
+	if err := a.store.SetLocale(ctx, userID, loc); err != nil {
+		errorLog.Printf("Failed to set locale of user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrSaveSettings))
+		return
+	}
+
+	// The answer is already in the new language
+	ctx = withLocale(ctx, loc)
+	sendMessage(ctx, b, userID, tr(ctx, MsgLangSet))
+}
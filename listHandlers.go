@@ -2,45 +2,31 @@ package main
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
-	"gorm.io/gorm"
 )
 
-func listKeyboard(ctx context.Context, b *bot.Bot, userID int64) (*models.InlineKeyboardMarkup, error) {
-	db, err := getDb()
+func (a *app) listKeyboard(ctx context.Context, b *bot.Bot, userID int64) (*models.InlineKeyboardMarkup, error) {
+	lists, err := a.store.UserLists(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get database: %w", err)
+		return nil, err
 	}
 
-	var owners []ListOwners
-	if err := db.WithContext(ctx).Where("user_id = ?", userID).Find(&owners).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch list owners for user %d: %w", userID, err)
-	}
-
-	if len(owners) == 0 {
+	if len(lists) == 0 {
 		return nil, fmt.Errorf("no lists available for user %d", userID)
 	}
 
 	kb := &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{}}
 	var row []models.InlineKeyboardButton
 
-	for _, owner := range owners {
-		var list List
-		if err := db.WithContext(ctx).First(&list, "id = ?", owner.ListID).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				continue
-			}
-			errorLog.Printf("Failed to fetch list %d for user %d: %v", owner.ListID, userID, err)
-			return nil, fmt.Errorf("failed to fetch list %d: %w", owner.ListID, err)
-		}
+	for _, list := range lists {
 		row = append(row, models.InlineKeyboardButton{
 			Text:         list.Name,
-			CallbackData: fmt.Sprintf("selectList_%s", list.Name),
+			CallbackData: fmt.Sprintf("selectListId_%d", list.ID),
 		})
 	}
 
@@ -53,7 +39,7 @@ func listKeyboard(ctx context.Context, b *bot.Bot, userID int64) (*models.Inline
 	return kb, nil
 }
 
-func onListSelect(ctx context.Context, b *bot.Bot, update *models.Update) {
+func (a *app) onListSelect(ctx context.Context, b *bot.Bot, update *models.Update) {
 	if err := answerCallback(ctx, b, update); err != nil {
 		return
 	}
@@ -65,63 +51,74 @@ func onListSelect(ctx context.Context, b *bot.Bot, update *models.Update) {
 	}
 
 	if update.CallbackQuery == nil {
-		sendMessage(ctx, b, userID, ErrInvalidCallback)
-		return
-	}
-
-	parts := strings.Split(update.CallbackQuery.Data, "_")
-	if len(parts) != 2 {
-		sendMessage(ctx, b, userID, ErrInvalidCallback)
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
 		return
 	}
 
-	listName := parts[1]
-	if err := selectListByName(ctx, userID, listName); err != nil {
-		errorLog.Printf("Failed to select list '%s' for user %d: %v", listName, userID, err)
-		sendMessage(ctx, b, userID, ErrSelectList)
+	data := update.CallbackQuery.Data
+	if idStr, ok := strings.CutPrefix(data, "selectListId_"); ok {
+		listID, err := parseInt64(idStr)
+		if err != nil {
+			sendMessage(ctx, b, userID, tr(ctx, ErrInvalidID))
+			return
+		}
+		if err := a.store.SelectListByID(ctx, userID, listID); err != nil {
+			errorLog.Printf("Failed to select list %d for user %d: %v", listID, userID, err)
+			sendMessage(ctx, b, userID, tr(ctx, ErrSelectList))
+			return
+		}
+	} else if listName, ok := strings.CutPrefix(data, "selectList_"); ok {
+		// Buttons sent before lists were selected by ID carry the list name
+		if err := a.store.SelectListByName(ctx, userID, listName); err != nil {
+			errorLog.Printf("Failed to select list '%s' for user %d: %v", listName, userID, err)
+			sendMessage(ctx, b, userID, tr(ctx, ErrSelectList))
+			return
+		}
+	} else {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
 		return
 	}
 
-	list, err := getSelectedList(ctx, userID)
+	list, err := a.store.GetSelectedList(ctx, userID)
 	if err != nil {
 		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
-		sendMessage(ctx, b, userID, ErrNoActiveList)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
 		return
 	}
 
-	kb, err := listItemsKeyboard(ctx, b, list, userID)
+	kb, err := a.listItemsKeyboard(ctx, b, list, userID)
 	if err != nil {
 		errorLog.Printf("Failed to create keyboard for list %d, user %d: %v", list.ID, userID, err)
-		sendMessage(ctx, b, userID, ErrCreateMenu)
+		sendMessage(ctx, b, userID, tr(ctx, ErrCreateMenu))
 		return
 	}
 
 	sendInlineKeyboard(ctx, b, userID, fmt.Sprintf("%s:", list.Name), kb)
 }
 
-func selectListHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+func (a *app) selectListHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	userID, err := getUserID(update)
 	if err != nil {
 		errorLog.Printf("Failed to get user ID: %v", err)
 		return
 	}
 
-	maskedSelectListHandler(ctx, b, userID)
+	a.maskedSelectListHandler(ctx, b, userID)
 }
 
-func maskedSelectListHandler(ctx context.Context, b *bot.Bot, userID int64) {
-	kb, err := listKeyboard(ctx, b, userID)
+func (a *app) maskedSelectListHandler(ctx context.Context, b *bot.Bot, userID int64) {
+	kb, err := a.listKeyboard(ctx, b, userID)
 	if err != nil {
 		errorLog.Printf("Failed to create list keyboard for user %d: %v", userID, err)
-		sendMessage(ctx, b, userID, "Нет доступных списков. Создайте новый с помощью /new <название>")
+		sendMessage(ctx, b, userID, tr(ctx, MsgNoLists))
 		return
 	}
 
-	sendInlineKeyboard(ctx, b, userID, MsgSelectList, kb)
-	sendMessage(ctx, b, userID, MsgCreateNewList)
+	sendInlineKeyboard(ctx, b, userID, tr(ctx, MsgSelectList), kb)
+	sendMessage(ctx, b, userID, tr(ctx, MsgCreateNewList))
 }
 
-func newListHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+func (a *app) newListHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	userID, err := getUserID(update)
 	if err != nil {
 		errorLog.Printf("Failed to get user ID: %v", err)
@@ -129,22 +126,274 @@ func newListHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	}
 
 	if update.Message == nil {
-		sendMessage(ctx, b, userID, ErrInvalidCallback)
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
 		return
 	}
 
 	words := strings.Fields(update.Message.Text)
 	if len(words) < 2 {
-		helpHandler(ctx, b, update)
+		a.helpHandler(ctx, b, update)
 		return
 	}
 
 	name := strings.Join(words[1:], " ")
-	if err := createList(ctx, userID, name); err != nil {
+	if _, err := a.store.CreateList(ctx, userID, name); err != nil {
 		errorLog.Printf("Failed to create list '%s' for user %d: %v", name, userID, err)
-		sendMessage(ctx, b, userID, ErrCreateList)
+		sendMessage(ctx, b, userID, tr(ctx, ErrCreateList))
+		return
+	}
+
+	sendMessage(ctx, b, userID, tr(ctx, MsgListCreated, name))
+}
+
+func (a *app) renameListHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.Message == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	words := strings.Fields(update.Message.Text)
+	if len(words) < 2 {
+		a.helpHandler(ctx, b, update)
+		return
+	}
+
+	list, err := a.store.GetSelectedList(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
+		return
+	}
+
+	name := strings.Join(words[1:], " ")
+	if err := a.store.RenameList(ctx, userID, list.ID, name); err != nil {
+		errorLog.Printf("Failed to rename list %d to '%s' for user %d: %v", list.ID, name, userID, err)
+		sendStoreError(ctx, b, userID, err, ErrRenameList)
+		return
+	}
+	a.refresher.ListChanged(list.ID)
+
+	sendMessage(ctx, b, userID, tr(ctx, MsgListRenamed, name))
+}
+
+func (a *app) deleteListHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	list, err := a.store.GetSelectedList(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
+		return
+	}
+
+	kb := &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: tr(ctx, BtnDelete), CallbackData: fmt.Sprintf("deleteListConfirm_%d", list.ID)},
+				{Text: tr(ctx, BtnCancel), CallbackData: "deleteListCancel"},
+			},
+		},
+	}
+	sendInlineKeyboard(ctx, b, userID, tr(ctx, MsgDeleteListConfirm, list.Name), kb)
+}
+
+func (a *app) deleteListConfirmHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if err := answerCallback(ctx, b, update); err != nil {
+		return
+	}
+
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.CallbackQuery == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	idStr, ok := strings.CutPrefix(update.CallbackQuery.Data, "deleteListConfirm_")
+	if !ok {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	listID, err := parseInt64(idStr)
+	if err != nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidID))
+		return
+	}
+
+	list, err := a.store.GetList(ctx, listID)
+	if err != nil {
+		errorLog.Printf("Failed to get list %d for user %d: %v", listID, userID, err)
+		sendStoreError(ctx, b, userID, err, ErrDeleteList)
+		return
+	}
+
+	if err := a.store.DeleteList(ctx, userID, listID); err != nil {
+		errorLog.Printf("Failed to delete list %d for user %d: %v", listID, userID, err)
+		sendStoreError(ctx, b, userID, err, ErrDeleteList)
+		return
+	}
+
+	kb := &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: tr(ctx, BtnRestore), CallbackData: fmt.Sprintf("restoreList_%d", list.ID)},
+			},
+		},
+	}
+	sendInlineKeyboard(ctx, b, userID, tr(ctx, MsgListDeleted, list.Name), kb)
+}
+
+func (a *app) deleteListCancelHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if err := answerCallback(ctx, b, update); err != nil {
+		return
+	}
+
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	sendMessage(ctx, b, userID, tr(ctx, MsgDeleteCancelled))
+}
+
+func (a *app) restoreListHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
 		return
 	}
 
-	sendMessage(ctx, b, userID, fmt.Sprintf(MsgListCreated, name))
+	lists, err := a.store.DeletedLists(ctx, userID)
+	if err != nil || len(lists) == 0 {
+		errorLog.Printf("No deleted lists to restore for user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoDeletedLists))
+		return
+	}
+
+	kb := &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{}}
+	for _, list := range lists {
+		kb.InlineKeyboard = append(kb.InlineKeyboard, []models.InlineKeyboardButton{
+			{Text: list.Name, CallbackData: fmt.Sprintf("restoreList_%d", list.ID)},
+		})
+	}
+
+	sendInlineKeyboard(ctx, b, userID, tr(ctx, MsgSelectDeletedList), kb)
+}
+
+func (a *app) onListRestore(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if err := answerCallback(ctx, b, update); err != nil {
+		return
+	}
+
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.CallbackQuery == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	idStr, ok := strings.CutPrefix(update.CallbackQuery.Data, "restoreList_")
+	if !ok {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	listID, err := parseInt64(idStr)
+	if err != nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidID))
+		return
+	}
+
+	list, err := a.store.RestoreList(ctx, userID, listID)
+	if err != nil {
+		errorLog.Printf("Failed to restore list %d for user %d: %v", listID, userID, err)
+		sendStoreError(ctx, b, userID, err, ErrRestoreList)
+		return
+	}
+
+	sendMessage(ctx, b, userID, tr(ctx, MsgListRestored, list.Name))
+	a.drawListItemsHandler(ctx, b, update)
+}
+
+// retentionHandler shows or sets how long deleted items of the active list are kept
+func (a *app) retentionHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.Message == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	list, err := a.store.GetSelectedList(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
+		return
+	}
+
+	defaultDays, _ := trashDaysSetting()
+
+	words := strings.Fields(update.Message.Text)
+	if len(words) < 2 {
+		days := list.TrashDays
+		if days == 0 {
+			days = defaultDays
+		}
+		sendMessage(ctx, b, userID, tr(ctx, MsgRetention, list.Name, days))
+		return
+	}
+
+	days, err := strconv.Atoi(words[1])
+	if err != nil || days < 0 || days > maxTrashDays {
+		sendMessage(ctx, b, userID, tr(ctx, ErrRetentionArgs))
+		return
+	}
+
+	if err := a.store.SetTrashDays(ctx, userID, list.ID, days); err != nil {
+		errorLog.Printf("Failed to set trash retention of list %d for user %d: %v", list.ID, userID, err)
+		sendStoreError(ctx, b, userID, err, ErrSetRetention)
+		return
+	}
+
+	if days == 0 {
+		days = defaultDays
+	}
+	sendMessage(ctx, b, userID, tr(ctx, MsgRetentionSet, list.Name, days))
+}
+
+// uniqueListName adds a number to the name if the user already has a list called so
+func uniqueListName(lists []List, name string) string {
+	taken := make(map[string]bool)
+	for _, list := range lists {
+		taken[list.Name] = true
+	}
+	candidate := name
+	for i := 2; taken[candidate]; i++ {
+		candidate = fmt.Sprintf("%s (%d)", name, i)
+	}
+	return candidate
 }
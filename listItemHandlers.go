@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/go-telegram/bot"
@@ -15,18 +17,10 @@ const (
 	maxButtonsPerRow = 6
 )
 
-func listItemsKeyboard(ctx context.Context, b *bot.Bot, list List, userID int64) (*models.InlineKeyboardMarkup, error) {
-	db, err := getDb()
+func (a *app) listItemsKeyboard(ctx context.Context, b *bot.Bot, list List, userID int64) (*models.InlineKeyboardMarkup, error) {
+	items, err := a.store.ListItems(ctx, list.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get database: %w", err)
-	}
-
-	var items []ListItem
-	if err := db.WithContext(ctx).
-		Where("list_id = ? AND deleted_at IS NULL", list.ID).
-		Order("item_order ASC").
-		Find(&items).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch items for list %d: %w", list.ID, err)
+		return nil, err
 	}
 
 	kb := &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{}}
@@ -34,8 +28,21 @@ func listItemsKeyboard(ctx context.Context, b *bot.Bot, list List, userID int64)
 	lineLength := 0
 	buttonsInRow := 0
 
+	hasDone := false
 	for _, item := range items {
-		if buttonsInRow >= maxButtonsPerRow || lineLength+len(item.Name) >= maxLineLength {
+		// Tapping an item checks it off, tapping a checked off item brings it back
+		text := item.Label()
+		callback := fmt.Sprintf("itemDone_%d_%d_1", list.ID, item.ID)
+		if item.DueAt != nil && !item.Done {
+			text = "⏰ " + item.Label()
+		}
+		if item.Done {
+			text = "✅ " + item.Label()
+			callback = fmt.Sprintf("itemDone_%d_%d_0", list.ID, item.ID)
+			hasDone = true
+		}
+
+		if buttonsInRow >= maxButtonsPerRow || lineLength+len(text) >= maxLineLength {
 			if len(currentRow) > 0 {
 				kb.InlineKeyboard = append(kb.InlineKeyboard, currentRow)
 			}
@@ -45,10 +52,10 @@ func listItemsKeyboard(ctx context.Context, b *bot.Bot, list List, userID int64)
 		}
 
 		currentRow = append(currentRow, models.InlineKeyboardButton{
-			Text:         item.Name,
-			CallbackData: fmt.Sprintf("deleteListElement_%d_%d", list.ID, item.ID),
+			Text:         text,
+			CallbackData: callback,
 		})
-		lineLength += len(item.Name)
+		lineLength += len(text)
 		buttonsInRow++
 	}
 
@@ -56,6 +63,12 @@ func listItemsKeyboard(ctx context.Context, b *bot.Bot, list List, userID int64)
 		kb.InlineKeyboard = append(kb.InlineKeyboard, currentRow)
 	}
 
+	if hasDone {
+		kb.InlineKeyboard = append(kb.InlineKeyboard, []models.InlineKeyboardButton{
+			{Text: tr(ctx, BtnClearDone), CallbackData: fmt.Sprintf("clearDone_%d", list.ID)},
+		})
+	}
+
 	// Получаем URL веб-приложения из переменной окружения
 	webAppURL := os.Getenv("MISTER_LISTER_WEBAPP_URL")
 	if webAppURL == "" {
@@ -90,31 +103,63 @@ func listItemsKeyboard(ctx context.Context, b *bot.Bot, list List, userID int64)
 	return kb, nil
 }
 
-func drawListItemsHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+func (a *app) drawListItemsHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	userID, err := getUserID(update)
 	if err != nil {
 		errorLog.Printf("Failed to get user ID: %v", err)
 		return
 	}
 
-	list, err := getSelectedList(ctx, userID)
+	list, err := a.store.GetSelectedList(ctx, userID)
 	if err != nil {
 		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
-		sendMessage(ctx, b, userID, ErrNoActiveList)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
+		return
+	}
+
+	a.showList(ctx, b, update, userID, list)
+}
+
+func (a *app) listRedraw(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if err := answerCallback(ctx, b, update); err != nil {
 		return
 	}
 
-	kb, err := listItemsKeyboard(ctx, b, list, userID)
+	a.drawListItemsHandler(ctx, b, update)
+}
+
+// showList renders the list into the message the callback came from, so taps
+// don't leave stale keyboards behind. A new message is sent for commands and
+// whenever the edit fails, for example because the message was deleted.
+func (a *app) showList(ctx context.Context, b *bot.Bot, update *models.Update, userID int64, list List) {
+	kb, err := a.listItemsKeyboard(ctx, b, list, userID)
 	if err != nil {
 		errorLog.Printf("Failed to create keyboard for list %d, user %d: %v", list.ID, userID, err)
-		sendMessage(ctx, b, userID, ErrCreateMenu)
+		sendMessage(ctx, b, userID, tr(ctx, ErrCreateMenu))
 		return
 	}
 
-	sendInlineKeyboard(ctx, b, userID, fmt.Sprintf("%s:", list.Name), kb)
+	text := fmt.Sprintf("%s:", list.Name)
+	if cq := update.CallbackQuery; cq != nil && cq.Message != nil {
+		if err := editInlineKeyboard(ctx, b, cq.Message, text, kb); err == nil {
+			a.trackListMessage(ctx, userID, list.ID, cq.Message.ID)
+			return
+		}
+	}
+
+	if msg, err := sendInlineKeyboard(ctx, b, userID, text, kb); err == nil {
+		a.trackListMessage(ctx, userID, list.ID, msg.ID)
+	}
+}
+
+// trackListMessage remembers the message so it is refreshed when others change the list
+func (a *app) trackListMessage(ctx context.Context, userID int64, listID int64, messageID int) {
+	if err := a.store.SaveListMessage(ctx, userID, listID, messageID); err != nil {
+		errorLog.Printf("Failed to track message %d of list %d for user %d: %v", messageID, listID, userID, err)
+	}
 }
 
-func listRedraw(ctx context.Context, b *bot.Bot, update *models.Update) {
+func (a *app) onListUndoDelete(ctx context.Context, b *bot.Bot, update *models.Update) {
 	if err := answerCallback(ctx, b, update); err != nil {
 		return
 	}
@@ -125,24 +170,28 @@ func listRedraw(ctx context.Context, b *bot.Bot, update *models.Update) {
 		return
 	}
 
-	list, err := getSelectedList(ctx, userID)
+	list, err := a.store.GetSelectedList(ctx, userID)
 	if err != nil {
 		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
-		sendMessage(ctx, b, userID, ErrNoActiveList)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
 		return
 	}
 
-	kb, err := listItemsKeyboard(ctx, b, list, userID)
-	if err != nil {
-		errorLog.Printf("Failed to create keyboard for list %d, user %d: %v", list.ID, userID, err)
-		sendMessage(ctx, b, userID, ErrCreateMenu)
+	if err := a.store.RestoreLastDeleted(ctx, userID, list.ID); err != nil {
+		if errors.Is(err, ErrNothingToRestore) {
+			sendMessage(ctx, b, userID, tr(ctx, ErrNoItemsToRestore))
+			return
+		}
+		errorLog.Printf("Failed to restore item for user %d, list %d: %v", userID, list.ID, err)
+		sendStoreError(ctx, b, userID, err, ErrRestoreItem)
 		return
 	}
+	a.refresher.ListChanged(list.ID)
 
-	sendInlineKeyboard(ctx, b, userID, fmt.Sprintf("%s:", list.Name), kb)
+	a.showList(ctx, b, update, userID, list)
 }
 
-func onListUndoDelete(ctx context.Context, b *bot.Bot, update *models.Update) {
+func (a *app) onListElementClick(ctx context.Context, b *bot.Bot, update *models.Update) {
 	if err := answerCallback(ctx, b, update); err != nil {
 		return
 	}
@@ -153,82 +202,75 @@ func onListUndoDelete(ctx context.Context, b *bot.Bot, update *models.Update) {
 		return
 	}
 
-	db, err := getDb()
+	if update.CallbackQuery == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	parts := strings.Split(update.CallbackQuery.Data, "_")
+	if len(parts) != 3 {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	listID, err := parseInt64(parts[1])
 	if err != nil {
-		errorLog.Printf("Failed to get database for user %d: %v", userID, err)
-		sendMessage(ctx, b, userID, ErrCreateMenu)
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidID))
 		return
 	}
 
-	list, err := getSelectedList(ctx, userID)
+	elementID, err := parseInt64(parts[2])
 	if err != nil {
-		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
-		sendMessage(ctx, b, userID, ErrNoActiveList)
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidID))
 		return
 	}
 
-	var lastDeleted ListItem
-	if err := db.WithContext(ctx).Unscoped().
-		Where("user_id = ? AND list_id = ? AND deleted_at IS NOT NULL", userID, list.ID).
-		Order("deleted_at DESC").
-		First(&lastDeleted).Error; err != nil {
-		errorLog.Printf("No deleted items to restore for user %d, list %d: %v", userID, list.ID, err)
-		sendMessage(ctx, b, userID, ErrNoItemsToRestore)
+	// Only members may see the list, the callback data could have been forged
+	if err := a.store.CheckRole(ctx, userID, listID, RoleViewer); err != nil {
+		errorLog.Printf("User %d tapped an item of list %d: %v", userID, listID, err)
+		sendStoreError(ctx, b, userID, err, ErrDeleteItem)
 		return
 	}
 
-	tx := db.WithContext(ctx).Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// Check for items with conflicting item_order
-	var currentItems []ListItem
-	if err := db.WithContext(ctx).
-		Where("user_id = ? AND list_id = ? AND deleted_at IS NULL AND item_order >= ?", userID, list.ID, lastDeleted.Item_order).
-		Order("item_order ASC").
-		Find(&currentItems).Error; err != nil {
-		tx.Rollback()
-		errorLog.Printf("Failed to fetch current items for user %d, list %d: %v", userID, list.ID, err)
-		sendMessage(ctx, b, userID, ErrRestoreItem)
-		return
-	}
-
-	// Shift items with item_order >= lastDeleted.Item_order
-	for _, curr := range currentItems {
-		if err := tx.Model(&ListItem{}).
-			Where("id = ? AND list_id = ? AND user_id = ?", curr.ID, list.ID, userID).
-			Update("item_order", curr.Item_order+1).Error; err != nil {
-			tx.Rollback()
-			errorLog.Printf("Failed to shift item %d for user %d, list %d: %v", curr.ID, userID, list.ID, err)
-			sendMessage(ctx, b, userID, ErrRestoreItem)
-			return
-		}
+	item, err := a.store.DeleteListElement(ctx, userID, listID, elementID)
+	switch {
+	case errors.Is(err, ErrItemNotFound):
+		// Someone already deleted the item, the keyboard was stale and only needs a redraw
+	case err != nil:
+		errorLog.Printf("Failed to delete item %d from list %d for user %d: %v", elementID, listID, userID, err)
+		sendStoreError(ctx, b, userID, err, ErrDeleteItem)
+		return
+	default:
+		a.notifier.ItemDeleted(userID, item)
+		a.refresher.ListChanged(listID)
+	}
+
+	// Redraw the list the tapped keyboard belongs to, which may not be the active one
+	list, err := a.store.GetList(ctx, listID)
+	if err != nil {
+		errorLog.Printf("Failed to get list %d for user %d: %v", listID, userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
+		return
 	}
 
-	// Restore the deleted item with its original item_order
-	if err := tx.Unscoped().
-		Model(&lastDeleted).
-		Updates(map[string]interface{}{"deleted_at": nil, "item_order": lastDeleted.Item_order}).Error; err != nil {
-		tx.Rollback()
-		errorLog.Printf("Failed to restore item %d for user %d, list %d: %v", lastDeleted.ID, userID, list.ID, err)
-		sendMessage(ctx, b, userID, ErrRestoreItem)
+	a.showList(ctx, b, update, userID, list)
+}
+
+func (a *app) listSwitch(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if err := answerCallback(ctx, b, update); err != nil {
 		return
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		tx.Rollback()
-		errorLog.Printf("Failed to commit transaction for user %d, list %d: %v", userID, list.ID, err)
-		sendMessage(ctx, b, userID, ErrRestoreItem)
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
 		return
 	}
 
-	listRedraw(ctx, b, update)
+	a.maskedSelectListHandler(ctx, b, userID)
 }
 
-func onListElementClick(ctx context.Context, b *bot.Bot, update *models.Update) {
+func (a *app) onItemDone(ctx context.Context, b *bot.Bot, update *models.Update) {
 	if err := answerCallback(ctx, b, update); err != nil {
 		return
 	}
@@ -240,38 +282,65 @@ func onListElementClick(ctx context.Context, b *bot.Bot, update *models.Update)
 	}
 
 	if update.CallbackQuery == nil {
-		sendMessage(ctx, b, userID, ErrInvalidCallback)
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
 		return
 	}
 
+	// The callback says whether to check the item off or bring it back,
+	// so a stale keyboard never flips the state the wrong way
 	parts := strings.Split(update.CallbackQuery.Data, "_")
-	if len(parts) != 3 {
-		sendMessage(ctx, b, userID, ErrInvalidCallback)
+	if len(parts) != 4 {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
 		return
 	}
 
 	listID, err := parseInt64(parts[1])
 	if err != nil {
-		sendMessage(ctx, b, userID, ErrInvalidID)
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidID))
 		return
 	}
 
-	elementID, err := parseInt64(parts[2])
+	itemID, err := parseInt64(parts[2])
 	if err != nil {
-		sendMessage(ctx, b, userID, ErrInvalidID)
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidID))
 		return
 	}
 
-	if err := deleteListElement(ctx, userID, listID, elementID); err != nil {
-		errorLog.Printf("Failed to delete item %d from list %d for user %d: %v", elementID, listID, userID, err)
-		sendMessage(ctx, b, userID, ErrDeleteItem)
+	// Only members may see the list, the callback data could have been forged
+	if err := a.store.CheckRole(ctx, userID, listID, RoleViewer); err != nil {
+		errorLog.Printf("User %d marked an item of list %d: %v", userID, listID, err)
+		sendStoreError(ctx, b, userID, err, ErrMarkItem)
+		return
+	}
+
+	// Checking off takes one piece of a counted item at a time
+	if parts[3] == "1" {
+		_, err = a.store.CheckOffItem(ctx, userID, listID, itemID)
+	} else {
+		_, err = a.store.SetItemDone(ctx, userID, listID, itemID, false)
+	}
+	switch {
+	case errors.Is(err, ErrItemNotFound):
+		// Someone already deleted the item, the keyboard was stale and only needs a redraw
+	case err != nil:
+		errorLog.Printf("Failed to mark item %d in list %d for user %d: %v", itemID, listID, userID, err)
+		sendStoreError(ctx, b, userID, err, ErrMarkItem)
+		return
+	default:
+		a.refresher.ListChanged(listID)
+	}
+
+	list, err := a.store.GetList(ctx, listID)
+	if err != nil {
+		errorLog.Printf("Failed to get list %d for user %d: %v", listID, userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
 		return
 	}
 
-	listRedraw(ctx, b, update)
+	a.showList(ctx, b, update, userID, list)
 }
 
-func listSwitch(ctx context.Context, b *bot.Bot, update *models.Update) {
+func (a *app) onClearDone(ctx context.Context, b *bot.Bot, update *models.Update) {
 	if err := answerCallback(ctx, b, update); err != nil {
 		return
 	}
@@ -282,5 +351,112 @@ func listSwitch(ctx context.Context, b *bot.Bot, update *models.Update) {
 		return
 	}
 
-	maskedSelectListHandler(ctx, b, userID)
+	if update.CallbackQuery == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	idStr, ok := strings.CutPrefix(update.CallbackQuery.Data, "clearDone_")
+	if !ok {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	listID, err := parseInt64(idStr)
+	if err != nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidID))
+		return
+	}
+
+	items, err := a.store.ClearDoneItems(ctx, userID, listID)
+	if err != nil {
+		errorLog.Printf("Failed to clear done items of list %d for user %d: %v", listID, userID, err)
+		sendStoreError(ctx, b, userID, err, ErrClearDone)
+		return
+	}
+	for _, item := range items {
+		a.notifier.ItemDeleted(userID, item)
+	}
+	a.refresher.ListChanged(listID)
+
+	list, err := a.store.GetList(ctx, listID)
+	if err != nil {
+		errorLog.Printf("Failed to get list %d for user %d: %v", listID, userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
+		return
+	}
+
+	a.showList(ctx, b, update, userID, list)
+}
+
+// editItemHandler handles /edit <n> <text>, where n is the item's position in the list.
+// Without arguments it shows the numbered items.
+func (a *app) editItemHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.Message == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	words := strings.Fields(update.Message.Text)
+	if words[0] != "/edit" {
+		sendMessage(ctx, b, userID, tr(ctx, ErrUnknownCommand))
+		return
+	}
+
+	list, err := a.store.GetSelectedList(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
+		return
+	}
+
+	items, err := a.store.ListItems(ctx, list.ID)
+	if err != nil {
+		errorLog.Printf("Failed to get items of list %d for user %d: %v", list.ID, userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrEditItem))
+		return
+	}
+
+	if len(words) == 1 {
+		lines := []string{tr(ctx, MsgEditItems, list.Name)}
+		for i, item := range items {
+			lines = append(lines, fmt.Sprintf("%d. %s", i+1, item.Label()))
+		}
+		sendMessage(ctx, b, userID, strings.Join(lines, "\n"))
+		return
+	}
+
+	if len(words) < 3 {
+		sendMessage(ctx, b, userID, tr(ctx, ErrEditArgs))
+		return
+	}
+
+	n, err := strconv.Atoi(words[1])
+	if err != nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrEditArgs))
+		return
+	}
+	if n < 1 || n > len(items) {
+		sendMessage(ctx, b, userID, tr(ctx, ErrItemNumber))
+		return
+	}
+
+	name := strings.Join(words[2:], " ")
+	item, err := a.store.RenameItem(ctx, userID, items[n-1].ID, name)
+	if err != nil {
+		errorLog.Printf("Failed to rename item %d for user %d: %v", items[n-1].ID, userID, err)
+		sendStoreError(ctx, b, userID, err, ErrEditItem)
+		return
+	}
+	a.notifier.ItemRenamed(userID, item, name)
+	a.refresher.ListChanged(list.ID)
+
+	sendMessage(ctx, b, userID, tr(ctx, MsgItemRenamed, item.Name, name))
+	a.showList(ctx, b, update, userID, list)
 }
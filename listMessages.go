@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// ListMessage is the last message a user got with the keyboard of a list,
+// it is edited whenever someone changes the list
+type ListMessage struct {
+	ID        int64 `gorm:"primaryKey"`
+	UserID    int64 `gorm:"uniqueIndex:idx_list_messages_user_list"`
+	ListID    int64 `gorm:"uniqueIndex:idx_list_messages_user_list;index"`
+	MessageID int
+	UpdatedAt time.Time
+}
+
+// ListMessageRepository tracks rendered list messages
+type ListMessageRepository interface {
+	SaveListMessage(ctx context.Context, userID int64, listID int64, messageID int) error
+	ListMessages(ctx context.Context, listID int64) ([]ListMessage, error)
+	DeleteListMessage(ctx context.Context, userID int64, listID int64) error
+}
+
+// SaveListMessage remembers messageID as the user's current message of the list
+func (s *Store) SaveListMessage(ctx context.Context, userID int64, listID int64, messageID int) error {
+	msg := ListMessage{UserID: userID, ListID: listID, MessageID: messageID}
+	if err := s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "list_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"message_id", "updated_at"}),
+		}).
+		Create(&msg).Error; err != nil {
+		return fmt.Errorf("failed to save message of list %d for user %d: %w", listID, userID, err)
+	}
+	return nil
+}
+
+// ListMessages returns the tracked messages of the list's current members
+func (s *Store) ListMessages(ctx context.Context, listID int64) ([]ListMessage, error) {
+	var msgs []ListMessage
+	if err := s.db.WithContext(ctx).
+		Joins("JOIN list_owners ON list_owners.user_id = list_messages.user_id AND list_owners.list_id = list_messages.list_id AND list_owners.deleted_at IS NULL").
+		Where("list_messages.list_id = ?", listID).
+		Find(&msgs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch messages of list %d: %w", listID, err)
+	}
+	return msgs, nil
+}
+
+func (s *Store) DeleteListMessage(ctx context.Context, userID int64, listID int64) error {
+	if err := s.db.WithContext(ctx).
+		Where("user_id = ? AND list_id = ?", userID, listID).
+		Delete(&ListMessage{}).Error; err != nil {
+		return fmt.Errorf("failed to forget message of list %d for user %d: %w", listID, userID, err)
+	}
+	return nil
+}
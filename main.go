@@ -6,14 +6,19 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
+	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
@@ -21,19 +26,70 @@ import (
 
 var errorLog = log.New(os.Stderr, "ERROR\t", log.Ldate|log.Ltime|log.Lshortfile)
 
+// app holds the dependencies shared by the bot and the web app handlers
+type app struct {
+	store       Repository
+	botUsername string
+	notifier    *notifier
+	refresher   *listRefresher
+}
+
+func newApp(store Repository) *app {
+	return &app{store: store}
+}
+
 func main() {
+	migrateOnly := flag.Bool("migrate", false, "apply pending database migrations and exit")
+	dryRun := flag.Bool("dry-run", false, "run pending migrations in a rolled back transaction and exit, implies -migrate")
+	flag.Parse()
+
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
+	store, err := openStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+
+	if err := store.Migrate(ctx, *dryRun); err != nil {
+		log.Fatal(err)
+	}
+	if *migrateOnly || *dryRun {
+		return
+	}
+
+	if err := checkCatalogs(); err != nil {
+		log.Fatal(err)
+	}
+
+	a := newApp(store)
+
 	opts := []bot.Option{
-		bot.WithDefaultHandler(defaultHandler),
-		bot.WithCallbackQueryDataHandler("deleteListElement", bot.MatchTypePrefix, onListElementClick),
-		bot.WithCallbackQueryDataHandler("undoDeleteListElement", bot.MatchTypePrefix, onListUndoDelete),
-		bot.WithCallbackQueryDataHandler("redrawList", bot.MatchTypePrefix, listRedraw),
-		bot.WithCallbackQueryDataHandler("switchList", bot.MatchTypePrefix, listSwitch),
-		bot.WithCallbackQueryDataHandler("selectList", bot.MatchTypePrefix, onListSelect),
-		bot.WithCallbackQueryDataHandler("undoAllConfirm", bot.MatchTypeExact, undoAllConfirmHandler),
-		bot.WithCallbackQueryDataHandler("undoAllCancel", bot.MatchTypeExact, undoAllCancelHandler),
+		bot.WithMiddlewares(a.localeMiddleware),
+		bot.WithDefaultHandler(a.defaultHandler),
+		bot.WithCallbackQueryDataHandler("deleteListElement", bot.MatchTypePrefix, a.onListElementClick),
+		bot.WithCallbackQueryDataHandler("undoDeleteListElement", bot.MatchTypePrefix, a.onListUndoDelete),
+		bot.WithCallbackQueryDataHandler("redrawList", bot.MatchTypePrefix, a.listRedraw),
+		bot.WithCallbackQueryDataHandler("switchList", bot.MatchTypePrefix, a.listSwitch),
+		bot.WithCallbackQueryDataHandler("selectList", bot.MatchTypePrefix, a.onListSelect),
+		bot.WithCallbackQueryDataHandler("undoAllConfirm", bot.MatchTypeExact, a.undoAllConfirmHandler),
+		bot.WithCallbackQueryDataHandler("undoAllCancel", bot.MatchTypeExact, a.undoAllCancelHandler),
+		bot.WithCallbackQueryDataHandler("deleteListConfirm_", bot.MatchTypePrefix, a.deleteListConfirmHandler),
+		bot.WithCallbackQueryDataHandler("deleteListCancel", bot.MatchTypeExact, a.deleteListCancelHandler),
+		bot.WithCallbackQueryDataHandler("restoreList_", bot.MatchTypePrefix, a.onListRestore),
+		bot.WithCallbackQueryDataHandler("revokeMember_", bot.MatchTypePrefix, a.onMemberRevoke),
+		bot.WithCallbackQueryDataHandler("revokeInvite_", bot.MatchTypePrefix, a.onInviteRevoke),
+		bot.WithCallbackQueryDataHandler("itemDone_", bot.MatchTypePrefix, a.onItemDone),
+		bot.WithCallbackQueryDataHandler("clearDone_", bot.MatchTypePrefix, a.onClearDone),
+		bot.WithCallbackQueryDataHandler("dupKeep_", bot.MatchTypePrefix, a.onDuplicateKeep),
+		bot.WithCallbackQueryDataHandler("dupMerge_", bot.MatchTypePrefix, a.onDuplicateMerge),
+		bot.WithCallbackQueryDataHandler("dupRestore_", bot.MatchTypePrefix, a.onDuplicateRestore),
+		bot.WithCallbackQueryDataHandler("trashPage_", bot.MatchTypePrefix, a.onTrashPage),
+		bot.WithCallbackQueryDataHandler("trashRestore_", bot.MatchTypePrefix, a.onTrashRestore),
+		bot.WithCallbackQueryDataHandler("importTo_", bot.MatchTypePrefix, a.onImportConfirm),
+		bot.WithCallbackQueryDataHandler("importCancel_", bot.MatchTypePrefix, a.onImportCancel),
+		bot.WithCallbackQueryDataHandler("templateUse_", bot.MatchTypePrefix, a.onTemplateUse),
 	}
 
 	b, err := bot.New(os.Getenv("MISTER_LISTER_TOKEN"), opts...)
@@ -41,20 +97,55 @@ func main() {
 		log.Fatal(err)
 	}
 
-	if err := initDb(); err != nil {
+	me, err := b.GetMe(ctx)
+	if err != nil {
 		log.Fatal(err)
 	}
+	a.botUsername = me.Username
+	a.notifier = newNotifier(store, b)
+	a.refresher = newListRefresher(a, b)
 
 	// Register handlers
-	b.RegisterHandler(bot.HandlerTypeMessageText, "/start", bot.MatchTypeExact, startHandler)
-	b.RegisterHandler(bot.HandlerTypeMessageText, "/help", bot.MatchTypeExact, helpHandler)
-	b.RegisterHandler(bot.HandlerTypeMessageText, "/show", bot.MatchTypeExact, drawListItemsHandler)
-	b.RegisterHandler(bot.HandlerTypeMessageText, "/share", bot.MatchTypePrefix, shareHandler)
-	b.RegisterHandler(bot.HandlerTypeMessageText, "/list", bot.MatchTypeExact, selectListHandler)
-	b.RegisterHandler(bot.HandlerTypeMessageText, "/new", bot.MatchTypePrefix, newListHandler)
-	b.RegisterHandler(bot.HandlerTypeMessageText, "/me", bot.MatchTypeExact, meHandler)
-	b.RegisterHandler(bot.HandlerTypeMessageText, "/undo", bot.MatchTypeExact, undoHandler)
-	b.RegisterHandler(bot.HandlerTypeMessageText, "/app", bot.MatchTypeExact, appHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/start", bot.MatchTypePrefix, a.startHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/help", bot.MatchTypeExact, a.helpHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/show", bot.MatchTypeExact, a.drawListItemsHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/share", bot.MatchTypePrefix, a.shareHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/list", bot.MatchTypeExact, a.selectListHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/new", bot.MatchTypePrefix, a.newListHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/me", bot.MatchTypeExact, a.meHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/undo", bot.MatchTypeExact, a.undoHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/app", bot.MatchTypeExact, a.appHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/rename", bot.MatchTypePrefix, a.renameListHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/delete", bot.MatchTypeExact, a.deleteListHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/restore", bot.MatchTypeExact, a.restoreListHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/members", bot.MatchTypeExact, a.membersHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/leave", bot.MatchTypeExact, a.leaveHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/unshare", bot.MatchTypePrefix, a.unshareHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, regexp.MustCompile(`^/invite(\s|$)`), a.inviteHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/invites", bot.MatchTypeExact, a.invitesHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/notify", bot.MatchTypePrefix, a.notifyHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/commas", bot.MatchTypePrefix, a.commasHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/mute", bot.MatchTypeExact, a.muteHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/unmute", bot.MatchTypeExact, a.unmuteHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/edit", bot.MatchTypePrefix, a.editItemHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/trash", bot.MatchTypeExact, a.trashHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/retention", bot.MatchTypePrefix, a.retentionHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/export", bot.MatchTypePrefix, a.exportHandler)
+	b.RegisterHandlerMatchFunc(isDocument, a.importHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/clone", bot.MatchTypePrefix, a.cloneHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/template", bot.MatchTypePrefix, a.templateHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/schedule", bot.MatchTypePrefix, a.scheduleHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/due", bot.MatchTypeExact, a.dueHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/lang", bot.MatchTypePrefix, a.langHandler)
+
+	// Clean up old deleted items in the background
+	go runJanitor(ctx, store)
+
+	// Reset recurring lists on their schedules
+	go newScheduler(store, b, a.refresher).run(ctx)
+
+	// Remind list members of items that come due
+	go newReminderDispatcher(store, b).run(ctx)
 
 	// Start HTTP server for Web App and API on localhost
 	go func() {
@@ -63,21 +154,30 @@ func main() {
 			httpPort = "8080"
 		}
 		listenAddr := "127.0.0.1:" + httpPort
-		mux := http.NewServeMux()
-		mux.HandleFunc("/app", func(w http.ResponseWriter, r *http.Request) {
-			http.ServeFile(w, r, "webapp/index.html")
-		})
-		mux.HandleFunc("/api/items", validateTelegramAuth(b, getItemsHandler))
-		mux.HandleFunc("/api/delete", validateTelegramAuth(b, deleteItemHandler))
-		mux.HandleFunc("/api/reorder", validateTelegramAuth(b, reorderItemsHandler))
 		log.Printf("Starting HTTP server on %s", listenAddr)
-		log.Fatal(http.ListenAndServe(listenAddr, mux))
+		log.Fatal(http.ListenAndServe(listenAddr, a.httpMux(b)))
 	}()
 
 	b.Start(ctx)
 }
 
-func validateTelegramAuth(b *bot.Bot, next http.HandlerFunc) http.HandlerFunc {
+// httpMux builds the routes of the Web App and its API
+func (a *app) httpMux(b *bot.Bot) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/app", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "webapp/index.html")
+	})
+	mux.HandleFunc("/api/items", validateTelegramAuth(b, a.store, a.getItemsHandler))
+	mux.HandleFunc("/api/items/", validateTelegramAuth(b, a.store, a.patchItemHandler))
+	mux.HandleFunc("/api/delete", validateTelegramAuth(b, a.store, a.deleteItemHandler))
+	mux.HandleFunc("/api/reorder", validateTelegramAuth(b, a.store, a.reorderItemsHandler))
+	mux.HandleFunc("/api/done", validateTelegramAuth(b, a.store, a.doneItemHandler))
+	mux.HandleFunc("/api/clear-done", validateTelegramAuth(b, a.store, a.clearDoneHandler))
+	mux.HandleFunc("/api/export", validateTelegramAuth(b, a.store, a.exportItemsHandler(b)))
+	return mux
+}
+
+func validateTelegramAuth(b *bot.Bot, store SettingsRepository, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		initData := r.Header.Get("X-Telegram-Init-Data")
 		if initData == "" {
@@ -101,8 +201,9 @@ func validateTelegramAuth(b *bot.Bot, next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		// Add userID to request context
+		// Add userID and the user's locale to request context
 		ctx := context.WithValue(r.Context(), "userID", userID)
+		ctx = userContext(ctx, store, userID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}
 }
@@ -166,7 +267,7 @@ func parseInitDataUserID(initData string) (int64, error) {
 	return user.ID, nil
 }
 
-func getItemsHandler(w http.ResponseWriter, r *http.Request) {
+func (a *app) getItemsHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -178,33 +279,46 @@ func getItemsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	list, err := getSelectedList(r.Context(), userID)
+	list, err := a.store.GetSelectedList(r.Context(), userID)
 	if err != nil {
 		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
-		http.Error(w, ErrNoActiveList, http.StatusBadRequest)
+		http.Error(w, tr(r.Context(), ErrNoActiveList), http.StatusBadRequest)
 		return
 	}
 
-	items, err := getListItems(r.Context(), userID, list.ID)
+	items, err := a.store.ListItems(r.Context(), list.ID)
 	if err != nil {
 		errorLog.Printf("Failed to get items for user %d, list %d: %v", userID, list.ID, err)
 		http.Error(w, "Failed to fetch items", http.StatusInternalServerError)
 		return
 	}
 
+	role, err := a.store.GetRole(r.Context(), userID, list.ID)
+	if err != nil {
+		errorLog.Printf("Failed to get role of user %d in list %d: %v", userID, list.ID, err)
+		http.Error(w, tr(r.Context(), ErrNoPermission), http.StatusForbidden)
+		return
+	}
+
 	response := struct {
+		ListID   int64      `json:"listId"`
 		ListName string     `json:"listName"`
+		Role     Role       `json:"role"`
 		Items    []ListItem `json:"items"`
+		Locale   locale     `json:"locale"`
 	}{
+		ListID:   list.ID,
 		ListName: list.Name,
+		Role:     role,
 		Items:    items,
+		Locale:   localeFrom(r.Context()),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
-func deleteItemHandler(w http.ResponseWriter, r *http.Request) {
+func (a *app) deleteItemHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -225,16 +339,23 @@ func deleteItemHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := deleteListElement(r.Context(), userID, req.ListID, req.ItemID); err != nil {
+	item, err := a.store.DeleteListElement(r.Context(), userID, req.ListID, req.ItemID)
+	if err != nil {
 		errorLog.Printf("Failed to delete item %d from list %d for user %d: %v", req.ItemID, req.ListID, userID, err)
-		http.Error(w, ErrDeleteItem, http.StatusInternalServerError)
+		if errors.Is(err, ErrForbidden) {
+			http.Error(w, tr(r.Context(), ErrNoPermission), http.StatusForbidden)
+			return
+		}
+		http.Error(w, tr(r.Context(), ErrDeleteItem), http.StatusInternalServerError)
 		return
 	}
+	a.notifier.ItemDeleted(userID, item)
+	a.refresher.ListChanged(req.ListID)
 
 	w.WriteHeader(http.StatusOK)
 }
 
-func reorderItemsHandler(w http.ResponseWriter, r *http.Request) {
+func (a *app) reorderItemsHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -255,55 +376,259 @@ func reorderItemsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := reorderListItems(r.Context(), userID, req.ListID, req.ItemIDs); err != nil {
+	if err := a.store.ReorderListItems(r.Context(), userID, req.ListID, req.ItemIDs); err != nil {
 		errorLog.Printf("Failed to reorder items for user %d, list %d: %v", userID, req.ListID, err)
+		if errors.Is(err, ErrForbidden) {
+			http.Error(w, tr(r.Context(), ErrNoPermission), http.StatusForbidden)
+			return
+		}
 		http.Error(w, "Failed to reorder items", http.StatusInternalServerError)
 		return
 	}
+	a.refresher.ListChanged(req.ListID)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// patchItemHandler handles PATCH /api/items/{id}, which changes the item's text
+// and its due date. An empty due date clears it.
+func (a *app) patchItemHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		http.Error(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	itemID, err := parseInt64(strings.TrimPrefix(r.URL.Path, "/api/items/"))
+	if err != nil {
+		http.Error(w, tr(r.Context(), ErrInvalidID), http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Name  *string `json:"name"`
+		DueAt *string `json:"due_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Name == nil && req.DueAt == nil {
+		http.Error(w, "Nothing to change", http.StatusBadRequest)
+		return
+	}
+
+	var edit ItemEdit
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			http.Error(w, "Name cannot be empty", http.StatusBadRequest)
+			return
+		}
+		edit.Name = &name
+	}
+
+	if req.DueAt != nil {
+		edit.SetDue = true
+		if *req.DueAt != "" {
+			t, err := time.Parse(time.RFC3339, *req.DueAt)
+			if err != nil {
+				http.Error(w, "Invalid due date", http.StatusBadRequest)
+				return
+			}
+			edit.Due = &t
+		}
+	}
+
+	// EditItem returns the item as it was, which the rename notification needs
+	item, err := a.store.EditItem(r.Context(), userID, itemID, edit)
+	if err != nil {
+		errorLog.Printf("Failed to change item %d for user %d: %v", itemID, userID, err)
+		switch {
+		case errors.Is(err, ErrForbidden):
+			http.Error(w, tr(r.Context(), ErrNoPermission), http.StatusForbidden)
+		case errors.Is(err, ErrItemNotFound):
+			http.Error(w, "Item not found", http.StatusNotFound)
+		default:
+			http.Error(w, tr(r.Context(), ErrEditItem), http.StatusInternalServerError)
+		}
+		return
+	}
+	if edit.Name != nil {
+		a.notifier.ItemRenamed(userID, item, *edit.Name)
+	}
+	a.refresher.ListChanged(item.ListID)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *app) doneItemHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		http.Error(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		ListID int64 `json:"listId"`
+		ItemID int64 `json:"itemId"`
+		Done   bool  `json:"done"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := a.store.SetItemDone(r.Context(), userID, req.ListID, req.ItemID, req.Done); err != nil {
+		errorLog.Printf("Failed to mark item %d in list %d for user %d: %v", req.ItemID, req.ListID, userID, err)
+		if errors.Is(err, ErrForbidden) {
+			http.Error(w, tr(r.Context(), ErrNoPermission), http.StatusForbidden)
+			return
+		}
+		http.Error(w, tr(r.Context(), ErrMarkItem), http.StatusInternalServerError)
+		return
+	}
+	a.refresher.ListChanged(req.ListID)
 
 	w.WriteHeader(http.StatusOK)
 }
 
-func helpHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+func (a *app) clearDoneHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		http.Error(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		ListID int64 `json:"listId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	items, err := a.store.ClearDoneItems(r.Context(), userID, req.ListID)
+	if err != nil {
+		errorLog.Printf("Failed to clear done items of list %d for user %d: %v", req.ListID, userID, err)
+		if errors.Is(err, ErrForbidden) {
+			http.Error(w, tr(r.Context(), ErrNoPermission), http.StatusForbidden)
+			return
+		}
+		http.Error(w, tr(r.Context(), ErrClearDone), http.StatusInternalServerError)
+		return
+	}
+	for _, item := range items {
+		a.notifier.ItemDeleted(userID, item)
+	}
+	a.refresher.ListChanged(req.ListID)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// exportItemsHandler serves the active list as a file, ?format= is one of exportFormats
+// and ?deleted=1 adds the deleted items
+func (a *app) exportItemsHandler(b *bot.Bot) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, ok := r.Context().Value("userID").(int64)
+		if !ok {
+			http.Error(w, "User ID not found", http.StatusUnauthorized)
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = exportFormats[0]
+		}
+		if !isExportFormat(format) {
+			http.Error(w, tr(r.Context(), ErrExportArgs), http.StatusBadRequest)
+			return
+		}
+		withDeleted := r.URL.Query().Get("deleted") == "1"
+
+		list, err := a.store.GetSelectedList(r.Context(), userID)
+		if err != nil {
+			errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
+			http.Error(w, tr(r.Context(), ErrNoActiveList), http.StatusBadRequest)
+			return
+		}
+
+		data, _, err := a.exportList(r.Context(), b, userID, list, format, withDeleted)
+		if err != nil {
+			errorLog.Printf("Failed to export list %d as %s for user %d: %v", list.ID, format, userID, err)
+			if errors.Is(err, ErrForbidden) {
+				http.Error(w, tr(r.Context(), ErrNoPermission), http.StatusForbidden)
+				return
+			}
+			http.Error(w, tr(r.Context(), ErrExportList), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", exportContentTypes[format])
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+			"filename": exportFilename(list.Name, format),
+		}))
+		w.Write(data)
+	}
+}
+
+func (a *app) helpHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	userID, err := getUserID(update)
 	if err != nil {
 		errorLog.Printf("Failed to get user ID: %v", err)
 		return
 	}
-	sendMessage(ctx, b, userID, `Доступные команды:
-* /help — Показать справку
-* /show — Показать активный список
-* /new <название> — Создать новый список
-* /list — Выбрать активный список
-* /share <id> — Поделиться списком с пользователем
-* /me — Показать ваш ID
-* /undo — Восстановить все удалённые элементы списка
-* /app — Открыть список в приложении
-Связаться с автором: @uscr0`)
+	sendMessage(ctx, b, userID, tr(ctx, MsgHelp))
 }
 
-func startHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+func (a *app) startHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	userID, err := getUserID(update)
 	if err != nil {
 		errorLog.Printf("Failed to get user ID: %v", err)
 		return
 	}
-	sendMessage(ctx, b, userID, `Добро пожаловать в бот списков!
-Создайте первый список: /new <название>
-Доступные команды:`)
-	helpHandler(ctx, b, update)
+
+	// Invite links open the bot with /start <token>
+	if update.Message != nil {
+		if words := strings.Fields(update.Message.Text); len(words) > 1 {
+			a.redeemInvite(ctx, b, userID, words[1])
+			return
+		}
+	}
+	sendMessage(ctx, b, userID, tr(ctx, MsgWelcome))
+	a.helpHandler(ctx, b, update)
 }
 
-func meHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+func (a *app) meHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	userID, err := getUserID(update)
 	if err != nil {
 		errorLog.Printf("Failed to get user ID: %v", err)
 		return
 	}
-	sendMessage(ctx, b, userID, fmt.Sprintf("Ваш ID: %d", userID))
+	sendMessage(ctx, b, userID, tr(ctx, MsgYourID, userID))
 }
 
-func defaultHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+func (a *app) defaultHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	userID, err := getUserID(update)
 	if err != nil {
 		errorLog.Printf("Failed to get user ID: %v", err)
@@ -311,23 +636,59 @@ func defaultHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	}
 
 	if update.Message == nil || strings.HasPrefix(update.Message.Text, "/") {
-		sendMessage(ctx, b, userID, ErrUnknownCommand)
-		helpHandler(ctx, b, update)
+		sendMessage(ctx, b, userID, tr(ctx, ErrUnknownCommand))
+		a.helpHandler(ctx, b, update)
 		return
 	}
 
-	if err := addItem(ctx, userID, update.Message.Text); err != nil {
-		errorLog.Printf("Failed to add item for user %d: %v", userID, err)
-		sendMessage(ctx, b, userID, ErrAddItem)
-		helpHandler(ctx, b, update)
+	list, err := a.store.GetSelectedList(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
+		a.helpHandler(ctx, b, update)
 		return
 	}
 
-	sendMessage(ctx, b, userID, fmt.Sprintf(MsgItemAdded, update.Message.Text))
-	drawListItemsHandler(ctx, b, update)
+	settings, err := a.store.GetSettings(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get settings for user %d: %v", userID, err)
+	}
+
+	parsed := parseItems(update.Message.Text, time.Now(), settings.SplitCommas)
+	if len(parsed) == 0 {
+		sendMessage(ctx, b, userID, tr(ctx, ErrAddItem))
+		return
+	}
+
+	items, err := a.store.AddItems(ctx, userID, list.ID, parsed)
+	if err != nil {
+		errorLog.Printf("Failed to add items for user %d: %v", userID, err)
+		sendStoreError(ctx, b, userID, err, ErrAddItem)
+		a.helpHandler(ctx, b, update)
+		return
+	}
+	for _, item := range items {
+		a.notifier.ItemAdded(userID, item)
+	}
+	a.refresher.ListChanged(list.ID)
+
+	if len(items) == 1 {
+		sendMessage(ctx, b, userID, tr(ctx, MsgItemAdded, dueLabel(ctx, items[0])))
+	} else {
+		lines := []string{tr(ctx, MsgItemsAdded, len(items))}
+		for _, item := range items {
+			lines = append(lines, "• "+dueLabel(ctx, item))
+		}
+		sendMessage(ctx, b, userID, strings.Join(lines, "\n"))
+	}
+	a.drawListItemsHandler(ctx, b, update)
+
+	for _, item := range items {
+		a.offerDuplicate(ctx, b, userID, item)
+	}
 }
 
-func shareHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+func (a *app) shareHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	userID, err := getUserID(update)
 	if err != nil {
 		errorLog.Printf("Failed to get user ID: %v", err)
@@ -335,61 +696,70 @@ func shareHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	}
 
 	if update.Message == nil {
-		sendMessage(ctx, b, userID, ErrInvalidCallback)
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
 		return
 	}
 
 	words := strings.Fields(update.Message.Text)
 	if len(words) < 2 {
-		helpHandler(ctx, b, update)
+		a.helpHandler(ctx, b, update)
 		return
 	}
 
-	selectedList, err := getSelectedList(ctx, userID)
+	selectedList, err := a.store.GetSelectedList(ctx, userID)
 	if err != nil {
 		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
-		sendMessage(ctx, b, userID, ErrNoActiveList)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
 		return
 	}
 
 	sharedWithID, err := parseInt64(words[1])
 	if err != nil {
-		sendMessage(ctx, b, userID, fmt.Sprintf(ErrInvalidUserID, words[1]))
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidUserID))
 		return
 	}
 
 	if sharedWithID == userID {
-		sendMessage(ctx, b, userID, ErrShareWithSelf)
+		sendMessage(ctx, b, userID, tr(ctx, ErrShareWithSelf))
 		return
 	}
 
-	if err := addOwner(ctx, sharedWithID, selectedList.ID); err != nil {
+	role := RoleEditor
+	if len(words) > 2 {
+		var ok bool
+		if role, ok = parseRole(words[2]); !ok {
+			sendMessage(ctx, b, userID, tr(ctx, ErrInvalidRole))
+			return
+		}
+	}
+
+	if err := a.store.AddOwner(ctx, userID, selectedList.ID, sharedWithID, role); err != nil {
 		errorLog.Printf("Failed to share list %d for user %d: %v", selectedList.ID, userID, err)
-		sendMessage(ctx, b, userID, ErrShareList)
+		sendStoreError(ctx, b, userID, err, ErrShareList)
 		return
 	}
 
-	sendMessage(ctx, b, userID, MsgListShared)
+	sendMessage(ctx, b, userID, tr(ctx, MsgListShared, roleTitle(ctx, role)))
 }
 
-func appHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+func (a *app) appHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	userID, err := getUserID(update)
 	if err != nil {
 		errorLog.Printf("Failed to get user ID: %v", err)
 		return
 	}
 
-	list, err := getSelectedList(ctx, userID)
+	list, err := a.store.GetSelectedList(ctx, userID)
 	if err != nil {
 		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
-		sendMessage(ctx, b, userID, ErrNoActiveList)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
 		return
 	}
 
 	webAppURL := os.Getenv("MISTER_LISTER_WEBAPP_URL")
 	if webAppURL == "" {
 		errorLog.Printf("MISTER_LISTER_WEBAPP_URL is not set for user %d", userID)
-		sendMessage(ctx, b, userID, "Ошибка: Web App URL не настроен")
+		sendMessage(ctx, b, userID, tr(ctx, ErrWebAppURL))
 		return
 	}
 
@@ -402,7 +772,7 @@ func appHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 		InlineKeyboard: [][]models.InlineKeyboardButton{
 			{
 				{
-					Text: "Открыть приложение",
+					Text: tr(ctx, BtnOpenApp),
 					WebApp: &models.WebAppInfo{
 						URL: webAppURL,
 					},
@@ -410,51 +780,42 @@ func appHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 			},
 		},
 	}
-	sendInlineKeyboard(ctx, b, userID, fmt.Sprintf("Список '%s' в приложении:", list.Name), kb)
+	sendInlineKeyboard(ctx, b, userID, tr(ctx, MsgOpenApp, list.Name), kb)
 }
 
-func undoHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+func (a *app) undoHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	userID, err := getUserID(update)
 	if err != nil {
 		errorLog.Printf("Failed to get user ID: %v", err)
 		return
 	}
 
-	list, err := getSelectedList(ctx, userID)
+	list, err := a.store.GetSelectedList(ctx, userID)
 	if err != nil {
 		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
-		sendMessage(ctx, b, userID, ErrNoActiveList)
-		return
-	}
-
-	db, err := getDb()
-	if err != nil {
-		errorLog.Printf("Failed to get database for user %d: %v", userID, err)
-		sendMessage(ctx, b, userID, ErrCreateMenu)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
 		return
 	}
 
-	var deletedItems []ListItem
-	if err := db.WithContext(ctx).Unscoped().
-		Where("user_id = ? AND list_id = ? AND deleted_at IS NOT NULL", userID, list.ID).
-		Find(&deletedItems).Error; err != nil || len(deletedItems) == 0 {
+	deletedItems, err := a.store.DeletedItems(ctx, userID, list.ID)
+	if err != nil || len(deletedItems) == 0 {
 		errorLog.Printf("No deleted items to restore for user %d, list %d: %v", userID, list.ID, err)
-		sendMessage(ctx, b, userID, ErrNoItemsToRestore)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoItemsToRestore))
 		return
 	}
 
 	kb := &models.InlineKeyboardMarkup{
 		InlineKeyboard: [][]models.InlineKeyboardButton{
 			{
-				{Text: "Подтвердить", CallbackData: "undoAllConfirm"},
-				{Text: "Отменить", CallbackData: "undoAllCancel"},
+				{Text: tr(ctx, BtnConfirm), CallbackData: "undoAllConfirm"},
+				{Text: tr(ctx, BtnCancel), CallbackData: "undoAllCancel"},
 			},
 		},
 	}
-	sendInlineKeyboard(ctx, b, userID, fmt.Sprintf(MsgUndoConfirmFormat, len(deletedItems)), kb)
+	sendInlineKeyboard(ctx, b, userID, tr(ctx, MsgUndoConfirmFormat, len(deletedItems)), kb)
 }
 
-func undoAllConfirmHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+func (a *app) undoAllConfirmHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	if err := answerCallback(ctx, b, update); err != nil {
 		return
 	}
@@ -465,91 +826,27 @@ func undoAllConfirmHandler(ctx context.Context, b *bot.Bot, update *models.Updat
 		return
 	}
 
-	list, err := getSelectedList(ctx, userID)
+	list, err := a.store.GetSelectedList(ctx, userID)
 	if err != nil {
 		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
-		sendMessage(ctx, b, userID, ErrNoActiveList)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
 		return
 	}
 
-	db, err := getDb()
+	restored, err := a.store.RestoreAllDeleted(ctx, userID, list.ID)
 	if err != nil {
-		errorLog.Printf("Failed to get database for user %d: %v", userID, err)
-		sendMessage(ctx, b, userID, ErrCreateMenu)
-		return
-	}
-
-	var deletedItems []ListItem
-	if err := db.WithContext(ctx).Unscoped().
-		Where("user_id = ? AND list_id = ? AND deleted_at IS NOT NULL", userID, list.ID).
-		Order("item_order ASC").
-		Find(&deletedItems).Error; err != nil {
-		errorLog.Printf("Failed to fetch deleted items for user %d, list %d: %v", userID, list.ID, err)
-		sendMessage(ctx, b, userID, ErrRestoreAllItems)
-		return
-	}
-
-	tx := db.WithContext(ctx).Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// Get current items to check for order conflicts
-	var currentItems []ListItem
-	if err := db.WithContext(ctx).
-		Where("list_id = ? AND deleted_at IS NULL", list.ID).
-		Order("item_order ASC").
-		Find(&currentItems).Error; err != nil {
-		tx.Rollback()
-		errorLog.Printf("Failed to fetch current items for user %d, list %d: %v", userID, list.ID, err)
-		sendMessage(ctx, b, userID, ErrRestoreAllItems)
-		return
-	}
-
-	// Restore items with their original item_order, shifting conflicts
-	for _, item := range deletedItems {
-		// Check if item_order is occupied
-		for _, curr := range currentItems {
-			if curr.Item_order >= item.Item_order {
-				// Shift current item's order
-				if err := tx.Model(&ListItem{}).
-					Where("id = ? AND list_id = ?", curr.ID, list.ID).
-					Update("item_order", curr.Item_order+1).Error; err != nil {
-					tx.Rollback()
-					errorLog.Printf("Failed to shift item %d for user %d, list %d: %v", curr.ID, userID, list.ID, err)
-					sendMessage(ctx, b, userID, ErrRestoreAllItems)
-					return
-				}
-			}
-		}
-
-		// Restore the deleted item
-		if err := tx.Unscoped().
-			Model(&ListItem{}).
-			Where("id = ? AND list_id = ? AND user_id = ?", item.ID, list.ID, userID).
-			Updates(map[string]interface{}{"deleted_at": nil, "item_order": item.Item_order}).Error; err != nil {
-			tx.Rollback()
-			errorLog.Printf("Failed to restore item %d for user %d, list %d: %v", item.ID, userID, list.ID, err)
-			sendMessage(ctx, b, userID, ErrRestoreAllItems)
-			return
-		}
-	}
-
-	if err := tx.Commit().Error; err != nil {
-		tx.Rollback()
-		errorLog.Printf("Failed to commit transaction for user %d, list %d: %v", userID, list.ID, err)
-		sendMessage(ctx, b, userID, ErrRestoreAllItems)
+		errorLog.Printf("Failed to restore deleted items for user %d, list %d: %v", userID, list.ID, err)
+		sendStoreError(ctx, b, userID, err, ErrRestoreAllItems)
 		return
 	}
 
-	log.Printf("Restored %d deleted items for user %d, list %d", len(deletedItems), userID, list.ID)
-	sendMessage(ctx, b, userID, MsgUndoAllSuccess)
-	drawListItemsHandler(ctx, b, update)
+	log.Printf("Restored %d deleted items for user %d, list %d", restored, userID, list.ID)
+	a.refresher.ListChanged(list.ID)
+	sendMessage(ctx, b, userID, tr(ctx, MsgUndoAllSuccess))
+	a.drawListItemsHandler(ctx, b, update)
 }
 
-func undoAllCancelHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+func (a *app) undoAllCancelHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	if err := answerCallback(ctx, b, update); err != nil {
 		return
 	}
@@ -560,5 +857,5 @@ func undoAllCancelHandler(ctx context.Context, b *bot.Bot, update *models.Update
 		return
 	}
 
-	sendMessage(ctx, b, userID, MsgUndoCancelled)
-}
\ No newline at end of file
+	sendMessage(ctx, b, userID, tr(ctx, MsgUndoCancelled))
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// stubRepository keeps a single list in memory. Methods the tests don't need come from
+// the embedded nil Repository and panic when called.
+type stubRepository struct {
+	Repository
+	list    List
+	items   []ListItem
+	role    Role
+	deleted []int64
+	edits   []ItemEdit
+}
+
+func (r *stubRepository) GetSelectedList(ctx context.Context, userID int64) (List, error) {
+	if r.list.ID == 0 {
+		return List{}, errors.New("no list selected")
+	}
+	return r.list, nil
+}
+
+func (r *stubRepository) ListItems(ctx context.Context, listID int64) ([]ListItem, error) {
+	return r.items, nil
+}
+
+func (r *stubRepository) GetRole(ctx context.Context, userID int64, listID int64) (Role, error) {
+	return r.role, nil
+}
+
+func (r *stubRepository) DeleteListElement(ctx context.Context, userID int64, listID int64, elementID int64) (ListItem, error) {
+	if !r.role.allows(RoleEditor) {
+		return ListItem{}, ErrForbidden
+	}
+	r.deleted = append(r.deleted, elementID)
+	return ListItem{ID: elementID, ListID: listID}, nil
+}
+
+func (r *stubRepository) EditItem(ctx context.Context, userID int64, itemID int64, edit ItemEdit) (ListItem, error) {
+	r.edits = append(r.edits, edit)
+	return ListItem{ID: itemID, ListID: r.list.ID, Name: "milk"}, nil
+}
+
+// apiRequest builds a request as validateTelegramAuth passes it on
+func apiRequest(ctx context.Context, method, target, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	return r.WithContext(context.WithValue(ctx, "userID", int64(1)))
+}
+
+func TestGetItemsHandler(t *testing.T) {
+	store := &stubRepository{
+		list:  List{ID: 7, Name: "Продукты"},
+		items: []ListItem{{ID: 1, ListID: 7, Name: "молоко"}, {ID: 2, ListID: 7, Name: "хлеб", Done: true}},
+		role:  RoleViewer,
+	}
+	a := newApp(store)
+
+	w := httptest.NewRecorder()
+	a.getItemsHandler(w, apiRequest(withLocale(context.Background(), localeEn), http.MethodGet, "/api/items", ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+
+	var resp struct {
+		ListID   int64      `json:"listId"`
+		ListName string     `json:"listName"`
+		Role     Role       `json:"role"`
+		Items    []ListItem `json:"items"`
+		Locale   locale     `json:"locale"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ListID != 7 || resp.ListName != "Продукты" || resp.Role != RoleViewer || resp.Locale != localeEn {
+		t.Errorf("got list %d %q, role %q, locale %q", resp.ListID, resp.ListName, resp.Role, resp.Locale)
+	}
+	if len(resp.Items) != 2 || resp.Items[0].Name != "молоко" || !resp.Items[1].Done {
+		t.Errorf("got items %+v", resp.Items)
+	}
+}
+
+func TestGetItemsHandlerNoActiveList(t *testing.T) {
+	a := newApp(&stubRepository{})
+
+	w := httptest.NewRecorder()
+	a.getItemsHandler(w, apiRequest(withLocale(context.Background(), localeEn), http.MethodGet, "/api/items", ""))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if got, want := strings.TrimSpace(w.Body.String()), messagesEn[ErrNoActiveList]; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
+
+func TestDeleteItemHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    Role
+		status  int
+		deleted int
+	}{
+		{name: "editor", role: RoleEditor, status: http.StatusOK, deleted: 1},
+		{name: "viewer", role: RoleViewer, status: http.StatusForbidden, deleted: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &stubRepository{list: List{ID: 7}, role: tt.role}
+			a := newApp(store)
+
+			w := httptest.NewRecorder()
+			a.deleteItemHandler(w, apiRequest(context.Background(), http.MethodPost, "/api/delete", `{"listId": 7, "itemId": 3}`))
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if len(store.deleted) != tt.deleted {
+				t.Errorf("deleted %v, want %d items", store.deleted, tt.deleted)
+			}
+		})
+	}
+}
+
+func TestPatchItemHandler(t *testing.T) {
+	store := &stubRepository{list: List{ID: 7}, role: RoleEditor}
+	a := newApp(store)
+
+	w := httptest.NewRecorder()
+	a.patchItemHandler(w, apiRequest(context.Background(), http.MethodPatch, "/api/items/3",
+		`{"name": " oat milk ", "due_at": "2026-10-17T09:00:00Z"}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+
+	// The name and the due date are changed by a single store call
+	if len(store.edits) != 1 {
+		t.Fatalf("got %d edits, want 1", len(store.edits))
+	}
+	edit := store.edits[0]
+	if edit.Name == nil || *edit.Name != "oat milk" || !edit.SetDue || edit.Due == nil || edit.Due.Hour() != 9 {
+		t.Errorf("edit = %+v", edit)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+// messagesEn is the English catalog
+var messagesEn = map[msgKey]string{
+	ErrInvalidCallback:   "Invalid command data",
+	ErrNoActiveList:      "Couldn't get the active list",
+	ErrCreateMenu:        "Couldn't create the menu",
+	ErrInvalidID:         "Invalid ID",
+	ErrDeleteItem:        "Couldn't delete the item",
+	ErrRestoreItem:       "Couldn't restore the item",
+	ErrMarkItem:          "Couldn't check off the item",
+	ErrClearDone:         "Couldn't clear the checked off items",
+	ErrEditItem:          "Couldn't change the item",
+	ErrEditArgs:          "Use /edit <number> <new text>",
+	ErrItemNumber:        "The list has no item with that number",
+	ErrResolveDuplicate:  "Couldn't merge the items",
+	ErrDuplicateGone:     "This duplicate was already handled or the item was deleted",
+	ErrTrash:             "Couldn't open the trash",
+	ErrRetentionArgs:     "Use /retention <days>, from 1 to 365, or /retention 0 for the default",
+	ErrSetRetention:      "Couldn't change how long deleted items are kept",
+	ErrNoItemsToRestore:  "No deleted items to restore",
+	ErrSelectList:        "Couldn't select the list",
+	ErrCreateList:        "Couldn't create the list",
+	ErrShareList:         "Couldn't share the list",
+	ErrInvalidUserID:     "Give a valid user ID",
+	ErrShareWithSelf:     "You can't share a list with yourself",
+	ErrUnknownCommand:    "Unknown command",
+	ErrAddItem:           "Couldn't add the item",
+	ErrRestoreAllItems:   "Couldn't restore all items",
+	ErrRenameList:        "Couldn't rename the list",
+	ErrDeleteList:        "Couldn't delete the list",
+	ErrRestoreList:       "Couldn't restore the list",
+	ErrNoDeletedLists:    "No deleted lists",
+	ErrLeaveList:         "Couldn't leave the list",
+	ErrUnshareList:       "Couldn't revoke access to the list",
+	ErrListMembers:       "Couldn't get the list's members",
+	ErrNoPermission:      "You don't have permission to do that",
+	ErrInvalidRole:       "The role must be one of: owner, editor, viewer",
+	ErrCreateInvite:      "Couldn't create the invite",
+	ErrInviteArgs:        "Usage: /invite [owner|editor|viewer] [multi] [lifetime, such as 24h or 7d]",
+	ErrInviteExpired:     "The invite is no longer valid: it was revoked, expired or already used",
+	ErrListInvites:       "Couldn't get the invites",
+	ErrRevokeInvite:      "Couldn't revoke the invite",
+	ErrNotifyArgs:        "Use /notify on or /notify off",
+	ErrCommasArgs:        "Use /commas on or /commas off",
+	ErrSaveSettings:      "Couldn't save the settings",
+	ErrExportArgs:        "Use /export [txt|md|csv|json] [deleted]",
+	ErrExportList:        "Couldn't export the list",
+	ErrImportFile:        "Couldn't read the file",
+	ErrImportFormat:      ".txt, .md, .csv and .json files are supported",
+	ErrImportTooLarge:    "The file is too large, 1 MB at most",
+	ErrImportEmpty:       "No items found in the file",
+	ErrImportExpired:     "This import was already done, cancelled or has expired. Send the file again",
+	ErrImportList:        "Couldn't import the items",
+	ErrCloneList:         "Couldn't copy the list",
+	ErrTemplateArgs:      "Use /template save [name], /template use [name] or /template delete <name>",
+	ErrTemplates:         "Couldn't get the templates",
+	ErrTemplateNotFound:  "There's no template '%s'. All templates: /template",
+	ErrSaveTemplate:      "Couldn't save the template",
+	ErrUseTemplate:       "Couldn't create a list from the template",
+	ErrDeleteTemplate:    "Couldn't delete the template",
+	ErrScheduleArgs:      "Couldn't read the schedule. Examples: /schedule sat 09:00, /schedule every Saturday 9:00, /schedule mon,thu 20:00 template Cleaning, /schedule 0 9 * * 6 silent",
+	ErrSchedule:          "Couldn't get the schedule",
+	ErrSetSchedule:       "Couldn't change the schedule",
+	ErrOverdue:           "Couldn't get the overdue items",
+	ErrWebAppURL:         "Error: the Web App URL isn't configured",
+	ErrLangArgs:          "Use /lang ru, /lang en or /lang auto",
+	MsgListShared:        "List shared, role: %s",
+	MsgItemAdded:         "Added: %s",
+	MsgItemsAdded:        "Items added: %d",
+	MsgListCreated:       "Created the list '%s' and made it active",
+	MsgSelectList:        "Choose a list:",
+	MsgCreateNewList:     "Use /new <Name> to create a new list",
+	MsgUndoConfirmFormat: "Are you sure you want to restore %d deleted items of the current list? Only the items you deleted are restored.",
+	MsgUndoCancelled:     "Restore cancelled",
+	MsgUndoAllSuccess:    "All deleted items restored",
+	MsgListRenamed:       "List renamed to '%s'",
+	MsgDeleteListConfirm: "Delete the list '%s' for all members? It can be brought back with /restore",
+	MsgListDeleted:       "List '%s' deleted",
+	MsgDeleteCancelled:   "Deletion cancelled",
+	MsgSelectDeletedList: "Choose a list to restore:",
+	MsgListRestored:      "List '%s' restored and made active",
+	MsgListLeft:          "You left the list '%s'",
+	MsgListArchived:      "You were the last member, the list '%s' was archived. It can be brought back with /restore",
+	MsgListMembers:       "Members of the list '%s':",
+	MsgMemberRemoved:     "Access to the list revoked",
+	MsgAccessRevoked:     "Your access to the list '%s' was revoked",
+	MsgInviteCreated:     "Invite to the list '%s' with the role «%s», %s, valid until %s. Forward the link below:",
+	MsgInviteSingleUse:   "single-use",
+	MsgInviteMultiUse:    "multi-use",
+	MsgInvites:           "Invites to the list '%s':",
+	MsgNoInvites:         "No active invites. Create one with /invite",
+	MsgInviteRevoked:     "Invite revoked",
+	MsgInviteAccepted:    "You got access to the list '%s' with the role «%s», it's now active",
+	MsgInviteJoined:      "%s joined the list '%s'",
+	MsgNotifyOn:          "Notifications about changes in shared lists are on. Turn them off for a single list with /mute",
+	MsgNotifyOff:         "Notifications about changes in shared lists are off",
+	MsgNotifyStatus:      "Notifications are %s now. Use /notify on or /notify off",
+	MsgCommasOn:          "A single-line message is split into items on commas: \"milk, eggs, bread\". Turn it off with /commas off",
+	MsgCommasOff:         "Every line of a message is one item, commas don't split items. Turn splitting on with /commas on",
+	MsgListMuted:         "Notifications for the list '%s' are off",
+	MsgListUnmuted:       "Notifications for the list '%s' are back on",
+	MsgListChanged:       "Changes in the list '%s' by %s:",
+	MsgItemRenamed:       "Changed: %s → %s",
+	MsgDuplicateFound:    "«%s» is already on the list: «%s». What should I do?",
+	MsgDuplicateDeleted:  "«%s» was on the list and got deleted. What should I do?",
+	MsgDuplicateKept:     "Kept both items",
+	MsgDuplicateMerged:   "Merged: %s",
+	MsgDuplicateRestored: "Restored the deleted item: %s",
+	MsgTrash:             "Trash of the list '%s' (%d–%d of %d):",
+	MsgTrashEmpty:        "The trash of the list '%s' is empty",
+	MsgRetention:         "Deleted items of the list '%s' are kept for %d days. Change: /retention <days>",
+	MsgRetentionSet:      "Deleted items of the list '%s' are now kept for %d days",
+	MsgExported:          "List '%s', items: %d",
+	MsgExportDeleted:     "Deleted items",
+	MsgExportDeletedBy:   "deleted by %s %s",
+	MsgImportPreview:     "File '%s': %d items will be imported. Where should they go?",
+	MsgImportDuplicates:  "Already on the list '%s': %d",
+	MsgImportTitle:       "Import",
+	MsgImported:          "Imported %d items into the list '%s'",
+	MsgImportCancelled:   "Import cancelled",
+	MsgCloneName:         "%s (copy)",
+	MsgListCloned:        "Copied the list '%s' to '%s' and made the copy active",
+	MsgTemplateSaved:     "Template '%s' saved, items: %d. Start a list from it: /template use %[1]s",
+	MsgTemplateUsed:      "Created the list '%s' from the template and made it active",
+	MsgTemplateDeleted:   "Template '%s' deleted",
+	MsgTemplates:         "Choose a template to start a list from:",
+	MsgNoTemplates:       "No templates yet. Save the active list as a template: /template save [name]",
+	MsgSchedule:          "The list '%s' resets on the schedule «%s»: %s. Next reset: %s. Turn off: /schedule off",
+	MsgScheduleRestore:   "items are unchecked, items deleted since the previous reset come back",
+	MsgScheduleTemplate:  "the items are replaced with the items of the template '%s'",
+	MsgScheduleSilent:    ", without notifying the members",
+	MsgNoSchedule:        "The list '%s' has no schedule. Set one: /schedule sat 09:00",
+	MsgScheduleOff:       "The schedule of the list '%s' is off",
+	MsgListReset:         "The list '%s' was reset on its schedule",
+	MsgItemDue:           "⏰ Time for: %s (list '%s', due %s)",
+	MsgDueSuffix:         " (due %s)",
+	MsgOverdue:           "Overdue:",
+	MsgOverdueItem:       "• %s — list '%s', due %s",
+	MsgNoOverdue:         "Nothing is overdue",
+	MsgEditItems:         "Items of the list '%s'. To fix an item, send /edit <number> <new text>",
+	MsgHelp: `Available commands:
+* /help — Show this help
+* /show — Show the active list
+* /new <name> — Create a new list
+* /list — Choose the active list
+* /rename <name> — Rename the active list
+* /delete — Delete the active list
+* /restore — Restore a deleted list
+* /share <id> [owner|editor|viewer] — Share the list with a user
+* /invite [role] [multi] [lifetime] — Create an invite link to the list
+* /invites — Active invites
+* /members — Members of the active list
+* /unshare <id> — Revoke a user's access to the list
+* /leave — Leave the active list
+* /notify on|off — Notifications about changes in shared lists
+* /commas on|off — Split a single-line message into items on commas
+* /mute, /unmute — Turn notifications for the active list off or on
+* /me — Show your ID
+* /lang ru|en|auto — The bot's language, auto follows Telegram
+* /undo — Restore all items of the list you deleted
+* /trash — Trash: who deleted items and when, restore them one by one
+* /retention [days] — How many days to keep deleted items of the active list
+* /export [txt|md|csv|json] [deleted] — Export the active list as a file, deleted adds the deleted items
+* /clone [name] — Copy the active list with its items into a new one
+* /template save [name] — Save the active list as a template
+* /template use [name] — Create a list from a template, without a name shows all templates
+* /template delete <name> — Delete a template
+* /schedule <rule> [template <template>] [silent] — Reset the active list on a schedule, for example /schedule sat 09:00. /schedule shows the schedule, /schedule off turns it off
+* /due — Overdue items of all your lists
+* Send a .txt, .md, .csv or .json file to import its items into a new or the active list
+* /edit <number> <text> — Fix the text of an item
+* /app — Open the list in the app
+To be reminded of an item, add a due date at its end: "pay rent @friday 18:00", "@tomorrow", "@25.12", "@18:00"
+Contact the author: @uscr0`,
+	MsgWelcome: `Welcome to the list bot!
+Create your first list: /new <name>
+Available commands:`,
+	MsgYourID:         "Your ID: %d",
+	MsgNoLists:        "No lists available. Create one with /new <name>",
+	MsgOpenApp:        "The list '%s' in the app:",
+	MsgInviteLine:     "%d. %s, %s, until %s",
+	MsgNotifyEnabled:  "on",
+	MsgNotifyDisabled: "off",
+	MsgUnknownUser:    "someone unknown",
+	MsgRoleOwner:      "owner",
+	MsgRoleEditor:     "editor",
+	MsgRoleViewer:     "viewer",
+	MsgLang:           "Language: %s. Change: /lang ru or /lang en, /lang auto follows Telegram",
+	MsgLangSet:        "I speak English now",
+	MsgLangAuto:       "The language follows your Telegram settings again",
+	BtnConfirm:        "Confirm",
+	BtnCancel:         "Cancel",
+	BtnDelete:         "Delete",
+	BtnRestore:        "Restore",
+	BtnKeepBoth:       "Keep both",
+	BtnMerge:          "Merge",
+	BtnRestoreDeleted: "Restore the deleted one",
+	BtnImportNew:      "📥 Into a new list «%s»",
+	BtnImportActive:   "➕ Into «%s»",
+	BtnImportUnique:   "➕ Into «%s» without duplicates",
+	BtnImportAll:      "➕ With all duplicates",
+	BtnRevokeInvite:   "❌ Revoke %d",
+	BtnClearDone:      "🧹 Clear checked off",
+	BtnOpenApp:        "Open the app",
+	BtnLeaveList:      "🚪 Leave the list",
+}
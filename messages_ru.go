@@ -0,0 +1,207 @@
+package main
+
+// messagesRu is the Russian catalog, the reference every other locale is checked against
+var messagesRu = map[msgKey]string{
+	ErrInvalidCallback:   "Неверные данные команды",
+	ErrNoActiveList:      "Не удалось получить активный список",
+	ErrCreateMenu:        "Не удалось создать меню",
+	ErrInvalidID:         "Неверный ID",
+	ErrDeleteItem:        "Не удалось удалить элемент",
+	ErrRestoreItem:       "Не удалось восстановить элемент",
+	ErrMarkItem:          "Не удалось отметить элемент",
+	ErrClearDone:         "Не удалось убрать отмеченные элементы",
+	ErrEditItem:          "Не удалось изменить элемент",
+	ErrEditArgs:          "Используйте /edit <номер> <новый текст>",
+	ErrItemNumber:        "В списке нет элемента с таким номером",
+	ErrResolveDuplicate:  "Не удалось объединить элементы",
+	ErrDuplicateGone:     "Этот повтор уже обработан или элемент удалён",
+	ErrTrash:             "Не удалось открыть корзину",
+	ErrRetentionArgs:     "Используйте /retention <дней>, от 1 до 365, или /retention 0 для значения по умолчанию",
+	ErrSetRetention:      "Не удалось изменить срок хранения удалённых элементов",
+	ErrNoItemsToRestore:  "Нет удалённых элементов для восстановления",
+	ErrSelectList:        "Не удалось выбрать список",
+	ErrCreateList:        "Не удалось создать список",
+	ErrShareList:         "Не удалось поделиться списком",
+	ErrInvalidUserID:     "Укажите действительный ID пользователя",
+	ErrShareWithSelf:     "Нельзя поделиться списком с самим собой",
+	ErrUnknownCommand:    "Неизвестная команда",
+	ErrAddItem:           "Не удалось добавить элемент",
+	ErrRestoreAllItems:   "Не удалось восстановить все элементы",
+	ErrRenameList:        "Не удалось переименовать список",
+	ErrDeleteList:        "Не удалось удалить список",
+	ErrRestoreList:       "Не удалось восстановить список",
+	ErrNoDeletedLists:    "Нет удалённых списков",
+	ErrLeaveList:         "Не удалось покинуть список",
+	ErrUnshareList:       "Не удалось закрыть доступ к списку",
+	ErrListMembers:       "Не удалось получить участников списка",
+	ErrNoPermission:      "Недостаточно прав для этого действия",
+	ErrInvalidRole:       "Роль должна быть одной из: владелец, редактор, читатель",
+	ErrCreateInvite:      "Не удалось создать приглашение",
+	ErrInviteArgs:        "Использование: /invite [владелец|редактор|читатель] [многоразовое] [срок, например 24h или 7d]",
+	ErrInviteExpired:     "Приглашение недействительно: оно отозвано, истекло или уже использовано",
+	ErrListInvites:       "Не удалось получить приглашения",
+	ErrRevokeInvite:      "Не удалось отозвать приглашение",
+	ErrNotifyArgs:        "Используйте /notify on или /notify off",
+	ErrCommasArgs:        "Используйте /commas on или /commas off",
+	ErrSaveSettings:      "Не удалось сохранить настройки",
+	ErrExportArgs:        "Используйте /export [txt|md|csv|json] [deleted]",
+	ErrExportList:        "Не удалось выгрузить список",
+	ErrImportFile:        "Не удалось прочитать файл",
+	ErrImportFormat:      "Поддерживаются файлы .txt, .md, .csv и .json",
+	ErrImportTooLarge:    "Файл слишком большой, максимум 1 МБ",
+	ErrImportEmpty:       "В файле не нашлось элементов",
+	ErrImportExpired:     "Этот импорт уже выполнен, отменён или устарел. Отправьте файл ещё раз",
+	ErrImportList:        "Не удалось импортировать элементы",
+	ErrCloneList:         "Не удалось скопировать список",
+	ErrTemplateArgs:      "Используйте /template save [название], /template use [название] или /template delete <название>",
+	ErrTemplates:         "Не удалось получить шаблоны",
+	ErrTemplateNotFound:  "Шаблона '%s' нет. Все шаблоны: /template",
+	ErrSaveTemplate:      "Не удалось сохранить шаблон",
+	ErrUseTemplate:       "Не удалось создать список из шаблона",
+	ErrDeleteTemplate:    "Не удалось удалить шаблон",
+	ErrScheduleArgs:      "Не удалось разобрать расписание. Примеры: /schedule sat 09:00, /schedule каждую субботу 9:00, /schedule mon,thu 20:00 template Уборка, /schedule 0 9 * * 6 silent",
+	ErrSchedule:          "Не удалось получить расписание",
+	ErrSetSchedule:       "Не удалось изменить расписание",
+	ErrOverdue:           "Не удалось получить просроченные элементы",
+	MsgListShared:        "Поделились списком, роль: %s",
+	MsgItemAdded:         "Добавлено: %s",
+	MsgItemsAdded:        "Добавлено элементов: %d",
+	MsgListCreated:       "Создали список '%s' и сделали его активным",
+	MsgSelectList:        "Выберите список:",
+	MsgCreateNewList:     "Используйте /new <Название> для создания нового списка",
+	MsgUndoConfirmFormat: "Вы уверены, что хотите восстановить %d удалённых элементов текущего списка? Будут восстановлены только элементы, удалённые вами.",
+	MsgUndoCancelled:     "Восстановление отменено",
+	MsgUndoAllSuccess:    "Все удалённые элементы восстановлены",
+	MsgListRenamed:       "Список переименован в '%s'",
+	MsgDeleteListConfirm: "Удалить список '%s' у всех участников? Его можно будет восстановить командой /restore",
+	MsgListDeleted:       "Список '%s' удалён",
+	MsgDeleteCancelled:   "Удаление отменено",
+	MsgSelectDeletedList: "Выберите список для восстановления:",
+	MsgListRestored:      "Список '%s' восстановлен и сделан активным",
+	MsgListLeft:          "Вы покинули список '%s'",
+	MsgListArchived:      "Вы были последним участником, список '%s' перемещён в архив. Вернуть его можно командой /restore",
+	MsgListMembers:       "Участники списка '%s':",
+	MsgMemberRemoved:     "Доступ к списку закрыт",
+	MsgAccessRevoked:     "Вам закрыли доступ к списку '%s'",
+	MsgInviteCreated:     "Приглашение в список '%s' с ролью «%s», %s, действует до %s. Перешлите ссылку ниже:",
+	MsgInviteSingleUse:   "одноразовое",
+	MsgInviteMultiUse:    "многоразовое",
+	MsgInvites:           "Приглашения в список '%s':",
+	MsgNoInvites:         "Активных приглашений нет. Создайте новое командой /invite",
+	MsgInviteRevoked:     "Приглашение отозвано",
+	MsgInviteAccepted:    "Вы получили доступ к списку '%s' с ролью «%s», он стал активным",
+	MsgInviteJoined:      "%s: новый участник списка '%s'",
+	MsgNotifyOn:          "Уведомления об изменениях в общих списках включены. Отключить их для одного списка можно командой /mute",
+	MsgNotifyOff:         "Уведомления об изменениях в общих списках выключены",
+	MsgNotifyStatus:      "Уведомления сейчас %s. Используйте /notify on или /notify off",
+	MsgCommasOn:          "Сообщение в одну строку делится на элементы по запятым: «молоко, яйца, хлеб». Выключить: /commas off",
+	MsgCommasOff:         "Каждая строка сообщения — один элемент, запятые не разделяют элементы. Включить: /commas on",
+	MsgListMuted:         "Уведомления по списку '%s' отключены",
+	MsgListUnmuted:       "Уведомления по списку '%s' снова включены",
+	MsgListChanged:       "Изменения в списке '%s' от %s:",
+	MsgItemRenamed:       "Изменено: %s → %s",
+	MsgDuplicateFound:    "«%s» уже есть в списке: «%s». Что сделать?",
+	MsgDuplicateDeleted:  "«%s» уже был в списке и был удалён. Что сделать?",
+	MsgDuplicateKept:     "Оставили оба элемента",
+	MsgDuplicateMerged:   "Объединили: %s",
+	MsgDuplicateRestored: "Вернули удалённый элемент: %s",
+	MsgTrash:             "Корзина списка '%s' (%d–%d из %d):",
+	MsgTrashEmpty:        "Корзина списка '%s' пуста",
+	MsgRetention:         "Удалённые элементы списка '%s' хранятся %d дн. Изменить: /retention <дней>",
+	MsgRetentionSet:      "Удалённые элементы списка '%s' теперь хранятся %d дн.",
+	MsgExported:          "Список '%s', элементов: %d",
+	MsgExportDeleted:     "Удалённые элементы",
+	MsgExportDeletedBy:   "удалил(а) %s %s",
+	MsgImportPreview:     "Файл '%s': будет импортировано элементов: %d. Куда их добавить?",
+	MsgImportDuplicates:  "Уже есть в списке '%s': %d",
+	MsgImportTitle:       "Импорт",
+	MsgImported:          "Импортировано элементов: %d в список '%s'",
+	MsgImportCancelled:   "Импорт отменён",
+	MsgCloneName:         "%s (копия)",
+	MsgListCloned:        "Скопировали список '%s' в '%s' и сделали копию активной",
+	MsgTemplateSaved:     "Шаблон '%s' сохранён, элементов: %d. Создать по нему список: /template use %[1]s",
+	MsgTemplateUsed:      "Создали список '%s' по шаблону и сделали его активным",
+	MsgTemplateDeleted:   "Шаблон '%s' удалён",
+	MsgTemplates:         "Выберите шаблон, чтобы создать по нему список:",
+	MsgNoTemplates:       "Шаблонов пока нет. Сохраните активный список как шаблон: /template save [название]",
+	MsgSchedule:          "Список '%s' сбрасывается по расписанию «%s»: %s. Следующий сброс: %s. Отключить: /schedule off",
+	MsgScheduleRestore:   "отметки снимаются, удалённые с прошлого сброса элементы возвращаются",
+	MsgScheduleTemplate:  "элементы заменяются элементами шаблона '%s'",
+	MsgScheduleSilent:    ", без уведомления участников",
+	MsgNoSchedule:        "У списка '%s' нет расписания. Задать: /schedule sat 09:00",
+	MsgScheduleOff:       "Расписание списка '%s' отключено",
+	MsgListReset:         "Список '%s' сброшен по расписанию",
+	MsgItemDue:           "⏰ Пора: %s (список '%s', срок %s)",
+	MsgDueSuffix:         " (срок %s)",
+	MsgOverdue:           "Просрочено:",
+	MsgOverdueItem:       "• %s — список '%s', срок %s",
+	MsgNoOverdue:         "Просроченных элементов нет",
+	MsgEditItems:         "Элементы списка '%s'. Чтобы исправить элемент, отправьте /edit <номер> <новый текст>",
+	ErrWebAppURL:         "Ошибка: Web App URL не настроен",
+	ErrLangArgs:          "Используйте /lang ru, /lang en или /lang auto",
+	MsgHelp: `Доступные команды:
+* /help — Показать справку
+* /show — Показать активный список
+* /new <название> — Создать новый список
+* /list — Выбрать активный список
+* /rename <название> — Переименовать активный список
+* /delete — Удалить активный список
+* /restore — Восстановить удалённый список
+* /share <id> [владелец|редактор|читатель] — Поделиться списком с пользователем
+* /invite [роль] [многоразовое] [срок] — Создать ссылку-приглашение в список
+* /invites — Активные приглашения
+* /members — Участники активного списка
+* /unshare <id> — Закрыть пользователю доступ к списку
+* /leave — Покинуть активный список
+* /notify on|off — Уведомления об изменениях в общих списках
+* /commas on|off — Делить сообщение в одну строку на элементы по запятым
+* /mute, /unmute — Отключить или включить уведомления по активному списку
+* /me — Показать ваш ID
+* /lang ru|en|auto — Язык бота, auto — как в Telegram
+* /undo — Восстановить все элементы списка, удалённые вами
+* /trash — Корзина: кто и когда удалил элементы, восстановление по одному
+* /retention [дней] — Сколько дней хранить удалённые элементы активного списка
+* /export [txt|md|csv|json] [deleted] — Выгрузить активный список файлом, deleted добавит удалённые элементы
+* /clone [название] — Скопировать активный список с элементами в новый
+* /template save [название] — Сохранить активный список как шаблон
+* /template use [название] — Создать список по шаблону, без названия покажет все шаблоны
+* /template delete <название> — Удалить шаблон
+* /schedule <правило> [template <шаблон>] [silent] — Сбрасывать активный список по расписанию, например /schedule sat 09:00. /schedule покажет расписание, /schedule off отключит
+* /due — Просроченные элементы всех ваших списков
+* Отправьте файл .txt, .md, .csv или .json, чтобы импортировать элементы в новый или активный список
+* /edit <номер> <текст> — Исправить текст элемента
+* /app — Открыть список в приложении
+Чтобы напомнить об элементе, добавьте срок в конце: «оплатить аренду @пятница 18:00», «@завтра», «@25.12», «@18:00»
+Связаться с автором: @uscr0`,
+	MsgWelcome: `Добро пожаловать в бот списков!
+Создайте первый список: /new <название>
+Доступные команды:`,
+	MsgYourID:         "Ваш ID: %d",
+	MsgNoLists:        "Нет доступных списков. Создайте новый с помощью /new <название>",
+	MsgOpenApp:        "Список '%s' в приложении:",
+	MsgInviteLine:     "%d. %s, %s, до %s",
+	MsgNotifyEnabled:  "включены",
+	MsgNotifyDisabled: "выключены",
+	MsgUnknownUser:    "неизвестно кто",
+	MsgRoleOwner:      "владелец",
+	MsgRoleEditor:     "редактор",
+	MsgRoleViewer:     "читатель",
+	MsgLang:           "Язык: %s. Сменить: /lang ru или /lang en, /lang auto — как в Telegram",
+	MsgLangSet:        "Теперь я говорю по-русски",
+	MsgLangAuto:       "Язык снова выбирается по настройкам Telegram",
+	BtnConfirm:        "Подтвердить",
+	BtnCancel:         "Отменить",
+	BtnDelete:         "Удалить",
+	BtnRestore:        "Восстановить",
+	BtnKeepBoth:       "Оставить оба",
+	BtnMerge:          "Объединить",
+	BtnRestoreDeleted: "Вернуть удалённый",
+	BtnImportNew:      "📥 В новый список «%s»",
+	BtnImportActive:   "➕ В «%s»",
+	BtnImportUnique:   "➕ В «%s» без повторов",
+	BtnImportAll:      "➕ Со всеми повторами",
+	BtnRevokeInvite:   "❌ Отозвать %d",
+	BtnClearDone:      "🧹 Убрать отмеченные",
+	BtnOpenApp:        "Открыть приложение",
+	BtnLeaveList:      "🚪 Покинуть список",
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SchemaVersion records every migration applied to the database
+type SchemaVersion struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (SchemaVersion) TableName() string {
+	return "schema_version"
+}
+
+// migration is a single schema change. Migrations are applied in slice order
+// and must never be edited or reordered once released, only appended.
+type migration struct {
+	version int
+	name    string
+	up      func(tx *gorm.DB) error
+}
+
+var migrations = []migration{
+	{version: 1, name: "initial schema", up: migrateInitialSchema},
+	{version: 2, name: "unique list owners", up: migrateUniqueListOwners},
+	{version: 3, name: "list owner roles", up: migrateListOwnerRoles},
+	{version: 4, name: "invites", up: migrateInvites},
+	{version: 5, name: "notification settings", up: migrateNotificationSettings},
+	{version: 6, name: "list messages", up: migrateListMessages},
+	{version: 7, name: "done items", up: migrateDoneItems},
+	{version: 8, name: "item quantities", up: migrateItemQuantities},
+	{version: 9, name: "item deleters", up: migrateItemDeleters},
+	{version: 10, name: "list trash retention", up: migrateListTrashDays},
+	{version: 11, name: "list templates", up: migrateListTemplates},
+	{version: 12, name: "list schedules", up: migrateListSchedules},
+	{version: 13, name: "item due dates", up: migrateItemDueDates},
+	{version: 14, name: "user locales", up: migrateUserLocales},
+	{version: 15, name: "comma splitting", up: migrateSplitCommas},
+	{version: 16, name: "pending imports", up: migratePendingImports},
+}
+
+// errDryRun rolls back the dry-run transaction once every migration has been tried
+var errDryRun = errors.New("dry run")
+
+// Migrate applies pending migrations. With dryRun set the migrations are run
+// inside a transaction that is rolled back, so nothing is written.
+func (s *Store) Migrate(ctx context.Context, dryRun bool) error {
+	db := s.db.WithContext(ctx)
+
+	var current int
+	if db.Migrator().HasTable(&SchemaVersion{}) {
+		if err := db.Model(&SchemaVersion{}).Select("COALESCE(MAX(version), 0)").Scan(&current).Error; err != nil {
+			return fmt.Errorf("failed to read schema version: %w", err)
+		}
+	}
+
+	var pending []migration
+	for _, m := range migrations {
+		if m.version > current {
+			pending = append(pending, m)
+		}
+	}
+
+	if len(pending) == 0 {
+		log.Printf("Database schema is up to date at version %d", current)
+		return nil
+	}
+
+	if dryRun {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, m := range pending {
+				log.Printf("Dry run: applying migration %d (%s)", m.version, m.name)
+				if err := m.up(tx); err != nil {
+					return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+				}
+			}
+			return errDryRun
+		})
+		if !errors.Is(err, errDryRun) {
+			return err
+		}
+		log.Printf("Dry run: %d migrations would be applied, rolled back", len(pending))
+		return nil
+	}
+
+	for _, m := range pending {
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.up(tx); err != nil {
+				return err
+			}
+			if err := tx.AutoMigrate(&SchemaVersion{}); err != nil {
+				return fmt.Errorf("failed to create schema_version table: %w", err)
+			}
+			return tx.Create(&SchemaVersion{Version: m.version, Name: m.name, AppliedAt: time.Now()}).Error
+		}); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+		}
+		log.Printf("Applied migration %d (%s)", m.version, m.name)
+	}
+	return nil
+}
+
+// migrateInitialSchema creates the tables as they were before versioned migrations.
+// The models are copied here so later changes to them don't alter this step.
+func migrateInitialSchema(tx *gorm.DB) error {
+	type List struct {
+		gorm.Model
+		ID   int64  `gorm:"primaryKey"`
+		Name string `gorm:"not null"`
+	}
+
+	type Settings struct {
+		ID           int64
+		UserID       int64 `gorm:"primaryKey"`
+		SelectedList int64
+	}
+
+	type ListOwners struct {
+		gorm.Model
+		UserID int64 `gorm:"index"`
+		ListID int64
+	}
+
+	type ListItem struct {
+		gorm.Model
+		ID         int64  `gorm:"primaryKey"`
+		UserID     int64  `gorm:"index"`
+		Name       string `gorm:"not null"`
+		ListID     int64
+		Item_order int `gorm:"default:0"`
+	}
+
+	return tx.AutoMigrate(&List{}, &ListItem{}, &Settings{}, &ListOwners{})
+}
+
+// migrateUniqueListOwners drops duplicate owner links and forbids new ones
+func migrateUniqueListOwners(tx *gorm.DB) error {
+	if err := tx.Exec(`DELETE FROM list_owners WHERE id NOT IN (
+		SELECT MIN(id) FROM list_owners GROUP BY user_id, list_id)`).Error; err != nil {
+		return fmt.Errorf("failed to remove duplicate list owners: %w", err)
+	}
+	return tx.Exec("CREATE UNIQUE INDEX idx_list_owners_user_list ON list_owners (user_id, list_id)").Error
+}
+
+// migrateListOwnerRoles adds roles, everyone who had access so far keeps full rights
+func migrateListOwnerRoles(tx *gorm.DB) error {
+	return tx.Exec("ALTER TABLE list_owners ADD COLUMN role TEXT NOT NULL DEFAULT 'owner'").Error
+}
+
+func migrateInvites(tx *gorm.DB) error {
+	type Invite struct {
+		gorm.Model
+		Token     string `gorm:"uniqueIndex;not null"`
+		ListID    int64  `gorm:"index"`
+		CreatedBy int64
+		Role      string `gorm:"not null"`
+		MaxUses   int
+		Uses      int
+		ExpiresAt time.Time
+	}
+
+	return tx.AutoMigrate(&Invite{})
+}
+
+// migrateNotificationSettings adds the opt-in for change notifications and per-list mutes
+func migrateNotificationSettings(tx *gorm.DB) error {
+	if err := tx.Exec("ALTER TABLE settings ADD COLUMN notify BOOLEAN NOT NULL DEFAULT false").Error; err != nil {
+		return err
+	}
+	return tx.Exec("ALTER TABLE list_owners ADD COLUMN muted BOOLEAN NOT NULL DEFAULT false").Error
+}
+
+func migrateListMessages(tx *gorm.DB) error {
+	type ListMessage struct {
+		ID        int64 `gorm:"primaryKey"`
+		UserID    int64 `gorm:"uniqueIndex:idx_list_messages_user_list"`
+		ListID    int64 `gorm:"uniqueIndex:idx_list_messages_user_list;index"`
+		MessageID int
+		UpdatedAt time.Time
+	}
+
+	return tx.AutoMigrate(&ListMessage{})
+}
+
+func migrateDoneItems(tx *gorm.DB) error {
+	return tx.Exec("ALTER TABLE list_items ADD COLUMN done BOOLEAN NOT NULL DEFAULT false").Error
+}
+
+func migrateItemQuantities(tx *gorm.DB) error {
+	if err := tx.Exec("ALTER TABLE list_items ADD COLUMN quantity DOUBLE PRECISION NOT NULL DEFAULT 0").Error; err != nil {
+		return err
+	}
+	return tx.Exec("ALTER TABLE list_items ADD COLUMN unit TEXT NOT NULL DEFAULT ''").Error
+}
+
+// migrateItemDeleters records who deleted an item, items deleted earlier keep 0
+func migrateItemDeleters(tx *gorm.DB) error {
+	return tx.Exec("ALTER TABLE list_items ADD COLUMN deleted_by BIGINT NOT NULL DEFAULT 0").Error
+}
+
+func migrateListTrashDays(tx *gorm.DB) error {
+	return tx.Exec("ALTER TABLE lists ADD COLUMN trash_days INTEGER NOT NULL DEFAULT 0").Error
+}
+
+func migrateListTemplates(tx *gorm.DB) error {
+	type ListTemplate struct {
+		ID        int64  `gorm:"primaryKey"`
+		UserID    int64  `gorm:"uniqueIndex:idx_list_templates_user_name"`
+		Name      string `gorm:"not null;uniqueIndex:idx_list_templates_user_name"`
+		CreatedAt time.Time
+		UpdatedAt time.Time
+	}
+	type TemplateItem struct {
+		ID         int64 `gorm:"primaryKey"`
+		TemplateID int64 `gorm:"index"`
+		Name       string
+		Quantity   float64
+		Unit       string
+		Item_order int
+	}
+
+	return tx.AutoMigrate(&ListTemplate{}, &TemplateItem{})
+}
+
+func migrateListSchedules(tx *gorm.DB) error {
+	type ListSchedule struct {
+		ID         int64  `gorm:"primaryKey"`
+		ListID     int64  `gorm:"uniqueIndex"`
+		Rule       string `gorm:"not null"`
+		TemplateID int64  `gorm:"not null;default:0"`
+		Notify     bool   `gorm:"not null"`
+		CreatedBy  int64
+		NextRun    time.Time `gorm:"index"`
+		Since      time.Time
+		CreatedAt  time.Time
+		UpdatedAt  time.Time
+	}
+
+	return tx.AutoMigrate(&ListSchedule{})
+}
+
+func migrateItemDueDates(tx *gorm.DB) error {
+	type ListItem struct {
+		DueAt    *time.Time `gorm:"index"`
+		Reminded bool       `gorm:"not null;default:false"`
+	}
+
+	if err := tx.Migrator().AddColumn(&ListItem{}, "DueAt"); err != nil {
+		return err
+	}
+	if err := tx.Migrator().AddColumn(&ListItem{}, "Reminded"); err != nil {
+		return err
+	}
+	return tx.Migrator().CreateIndex(&ListItem{}, "DueAt")
+}
+
+func migrateUserLocales(tx *gorm.DB) error {
+	if err := tx.Exec("ALTER TABLE settings ADD COLUMN locale TEXT NOT NULL DEFAULT ''").Error; err != nil {
+		return err
+	}
+	return tx.Exec("ALTER TABLE settings ADD COLUMN language_code TEXT NOT NULL DEFAULT ''").Error
+}
+
+func migrateSplitCommas(tx *gorm.DB) error {
+	return tx.Exec("ALTER TABLE settings ADD COLUMN split_commas BOOLEAN NOT NULL DEFAULT false").Error
+}
+
+func migratePendingImports(tx *gorm.DB) error {
+	type PendingImport struct {
+		ID        int64  `gorm:"primaryKey"`
+		UserID    int64  `gorm:"uniqueIndex"`
+		Token     string `gorm:"not null"`
+		ListID    int64
+		Title     string    `gorm:"not null"`
+		Items     string    `gorm:"not null"`
+		ExpiresAt time.Time `gorm:"index"`
+	}
+
+	return tx.AutoMigrate(&PendingImport{})
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot"
+)
+
+// notifyBatchWindow is how long changes are collected before other members are notified,
+// so adding a dozen items results in a single message
+const notifyBatchWindow = 30 * time.Second
+
+// notifyKey groups changes made by one user in one list
+type notifyKey struct {
+	listID  int64
+	actorID int64
+}
+
+// notifier batches item changes and sends them to the other members of the list
+// who turned notifications on with /notify
+type notifier struct {
+	store   Repository
+	bot     *bot.Bot
+	mu      sync.Mutex
+	pending map[notifyKey][]string
+}
+
+func newNotifier(store Repository, b *bot.Bot) *notifier {
+	return &notifier{
+		store:   store,
+		bot:     b,
+		pending: make(map[notifyKey][]string),
+	}
+}
+
+// ItemAdded records that actorID added the item to its list
+func (n *notifier) ItemAdded(actorID int64, item ListItem) {
+	n.record(notifyKey{listID: item.ListID, actorID: actorID}, "+ "+item.Label())
+}
+
+// ItemDeleted records that actorID deleted the item from its list
+func (n *notifier) ItemDeleted(actorID int64, item ListItem) {
+	n.record(notifyKey{listID: item.ListID, actorID: actorID}, "− "+item.Label())
+}
+
+// ItemRenamed records that actorID changed the text of the item to name
+func (n *notifier) ItemRenamed(actorID int64, item ListItem, name string) {
+	n.record(notifyKey{listID: item.ListID, actorID: actorID}, "✎ "+item.Name+" → "+name)
+}
+
+func (n *notifier) record(key notifyKey, change string) {
+	if n == nil {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, ok := n.pending[key]; !ok {
+		time.AfterFunc(notifyBatchWindow, func() { n.flush(key) })
+	}
+	n.pending[key] = append(n.pending[key], change)
+}
+
+func (n *notifier) flush(key notifyKey) {
+	n.mu.Lock()
+	changes := n.pending[key]
+	delete(n.pending, key)
+	n.mu.Unlock()
+
+	if len(changes) == 0 {
+		return
+	}
+
+	// The batch outlives the update that started it
+	ctx := context.Background()
+
+	recipients, err := n.store.NotificationRecipients(ctx, key.listID, key.actorID)
+	if err != nil {
+		errorLog.Printf("Failed to get notification recipients of list %d: %v", key.listID, err)
+		return
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	list, err := n.store.GetList(ctx, key.listID)
+	if err != nil {
+		errorLog.Printf("Failed to get list %d for notification: %v", key.listID, err)
+		return
+	}
+
+	actor := userDisplayName(ctx, n.bot, key.actorID)
+	body := strings.Join(changes, "\n")
+	for _, userID := range recipients {
+		text := tr(userContext(ctx, n.store, userID), MsgListChanged, list.Name, actor) + "\n" + body
+		sendMessage(ctx, n.bot, userID, text)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+func (a *app) notifyHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.Message == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	words := strings.Fields(update.Message.Text)
+	if words[0] != "/notify" {
+		sendMessage(ctx, b, userID, tr(ctx, ErrUnknownCommand))
+		return
+	}
+
+	if len(words) < 2 {
+		settings, err := a.store.GetSettings(ctx, userID)
+		if err != nil {
+			errorLog.Printf("Failed to get settings for user %d: %v", userID, err)
+			sendMessage(ctx, b, userID, tr(ctx, ErrNotifyArgs))
+			return
+		}
+		status := tr(ctx, MsgNotifyDisabled)
+		if settings.Notify {
+			status = tr(ctx, MsgNotifyEnabled)
+		}
+		sendMessage(ctx, b, userID, tr(ctx, MsgNotifyStatus, status))
+		return
+	}
+
+	var notify bool
+	switch strings.ToLower(words[1]) {
+	case "on", "вкл":
+		notify = true
+	case "off", "выкл":
+		notify = false
+	default:
+		sendMessage(ctx, b, userID, tr(ctx, ErrNotifyArgs))
+		return
+	}
+
+	if err := a.store.SetNotify(ctx, userID, notify); err != nil {
+		errorLog.Printf("Failed to set notifications for user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrSaveSettings))
+		return
+	}
+
+	if notify {
+		sendMessage(ctx, b, userID, tr(ctx, MsgNotifyOn))
+	} else {
+		sendMessage(ctx, b, userID, tr(ctx, MsgNotifyOff))
+	}
+}
+
+func (a *app) muteHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	a.setListMuted(ctx, b, update, true)
+}
+
+func (a *app) unmuteHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	a.setListMuted(ctx, b, update, false)
+}
+
+// setListMuted turns notifications about the active list off or back on for the user
+func (a *app) setListMuted(ctx context.Context, b *bot.Bot, update *models.Update, muted bool) {
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	list, err := a.store.GetSelectedList(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
+		return
+	}
+
+	if err := a.store.SetMuted(ctx, userID, list.ID, muted); err != nil {
+		errorLog.Printf("Failed to mute list %d for user %d: %v", list.ID, userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrSaveSettings))
+		return
+	}
+
+	if muted {
+		sendMessage(ctx, b, userID, tr(ctx, MsgListMuted, list.Name))
+	} else {
+		sendMessage(ctx, b, userID, tr(ctx, MsgListUnmuted, list.Name))
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+func (a *app) membersKeyboard(ctx context.Context, b *bot.Bot, list List, userID int64) (string, *models.InlineKeyboardMarkup, error) {
+	owners, err := a.store.ListMembers(ctx, list.ID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var role Role
+	for _, owner := range owners {
+		if owner.UserID == userID {
+			role = owner.Role
+		}
+	}
+
+	lines := []string{tr(ctx, MsgListMembers, list.Name)}
+	kb := &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{}}
+	for _, owner := range owners {
+		name := userDisplayName(ctx, b, owner.UserID)
+		lines = append(lines, fmt.Sprintf("• %s (%d) — %s", name, owner.UserID, roleTitle(ctx, owner.Role)))
+
+		// Only owners may revoke others, anyone may leave
+		text := "❌ " + name
+		if owner.UserID == userID {
+			text = tr(ctx, BtnLeaveList)
+		} else if !role.allows(RoleOwner) {
+			continue
+		}
+		kb.InlineKeyboard = append(kb.InlineKeyboard, []models.InlineKeyboardButton{
+			{Text: text, CallbackData: fmt.Sprintf("revokeMember_%d_%d", list.ID, owner.UserID)},
+		})
+	}
+
+	return strings.Join(lines, "\n"), kb, nil
+}
+
+func (a *app) membersHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	list, err := a.store.GetSelectedList(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
+		return
+	}
+
+	text, kb, err := a.membersKeyboard(ctx, b, list, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get members of list %d for user %d: %v", list.ID, userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrListMembers))
+		return
+	}
+
+	sendInlineKeyboard(ctx, b, userID, text, kb)
+}
+
+func (a *app) leaveHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	list, err := a.store.GetSelectedList(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
+		return
+	}
+
+	a.removeMember(ctx, b, userID, list, userID)
+}
+
+func (a *app) unshareHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.Message == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	words := strings.Fields(update.Message.Text)
+	if len(words) < 2 {
+		a.helpHandler(ctx, b, update)
+		return
+	}
+
+	list, err := a.store.GetSelectedList(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
+		return
+	}
+
+	memberID, err := parseInt64(words[1])
+	if err != nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidUserID))
+		return
+	}
+
+	a.removeMember(ctx, b, userID, list, memberID)
+}
+
+func (a *app) onMemberRevoke(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if err := answerCallback(ctx, b, update); err != nil {
+		return
+	}
+
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.CallbackQuery == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	parts := strings.Split(update.CallbackQuery.Data, "_")
+	if len(parts) != 3 {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	listID, err := parseInt64(parts[1])
+	if err != nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidID))
+		return
+	}
+
+	memberID, err := parseInt64(parts[2])
+	if err != nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidID))
+		return
+	}
+
+	list, err := a.store.GetList(ctx, listID)
+	if err != nil {
+		errorLog.Printf("Failed to get list %d for user %d: %v", listID, userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrUnshareList))
+		return
+	}
+
+	a.removeMember(ctx, b, userID, list, memberID)
+}
+
+// removeMember takes memberID off the list on behalf of userID and tells both sides about it
+func (a *app) removeMember(ctx context.Context, b *bot.Bot, userID int64, list List, memberID int64) {
+	archived, err := a.store.RemoveOwner(ctx, userID, list.ID, memberID)
+	if err != nil {
+		errorLog.Printf("Failed to remove user %d from list %d for user %d: %v", memberID, list.ID, userID, err)
+		if memberID == userID {
+			sendStoreError(ctx, b, userID, err, ErrLeaveList)
+		} else {
+			sendStoreError(ctx, b, userID, err, ErrUnshareList)
+		}
+		return
+	}
+
+	switch {
+	case archived:
+		sendMessage(ctx, b, userID, tr(ctx, MsgListArchived, list.Name))
+	case memberID == userID:
+		sendMessage(ctx, b, userID, tr(ctx, MsgListLeft, list.Name))
+	default:
+		sendMessage(ctx, b, userID, tr(ctx, MsgMemberRemoved))
+		sendMessage(ctx, b, memberID, tr(userContext(ctx, a.store, memberID), MsgAccessRevoked, list.Name))
+	}
+}
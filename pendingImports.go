@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// importTTL is how long an import preview waits for an answer
+const importTTL = 15 * time.Minute
+
+// PendingImport is a parsed file waiting for the user to pick where its items go.
+// It is kept in the database, so the preview survives restarts and its buttons work
+// on whichever replica gets the tap.
+type PendingImport struct {
+	ID        int64     `gorm:"primaryKey"`
+	UserID    int64     `gorm:"uniqueIndex"` // only the last file a user sent waits for an answer
+	Token     string    `gorm:"not null"`    // random, so buttons of an earlier preview never match a later one
+	ListID    int64     // the active list when the file was sent, 0 if there was none
+	Title     string    `gorm:"not null"`
+	Items     string    `gorm:"not null"` // the parsed items as JSON
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+// ErrNoPendingImport is returned for previews that expired, were answered or replaced by a newer file
+var ErrNoPendingImport = errors.New("no pending import")
+
+// ImportRepository keeps import previews until the user answers them
+type ImportRepository interface {
+	SavePendingImport(ctx context.Context, userID int64, listID int64, imported importedList) (string, error)
+	TakePendingImport(ctx context.Context, userID int64, token string) (int64, importedList, error)
+}
+
+// newImportToken returns a random token for the callback data of the preview buttons
+func newImportToken() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// SavePendingImport replaces the user's pending import and returns its token.
+// Expired previews of everyone are dropped on the way.
+func (s *Store) SavePendingImport(ctx context.Context, userID int64, listID int64, imported importedList) (string, error) {
+	token, err := newImportToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate import token: %w", err)
+	}
+
+	items, err := json.Marshal(imported.Items)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode import of user %d: %w", userID, err)
+	}
+
+	now := time.Now()
+	if err := s.db.WithContext(ctx).
+		Where("expires_at < ?", now).
+		Delete(&PendingImport{}).Error; err != nil {
+		return "", fmt.Errorf("failed to delete expired imports: %w", err)
+	}
+
+	imp := PendingImport{
+		UserID:    userID,
+		Token:     token,
+		ListID:    listID,
+		Title:     imported.Title,
+		Items:     string(items),
+		ExpiresAt: now.Add(importTTL),
+	}
+	if err := s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"token", "list_id", "title", "items", "expires_at"}),
+		}).
+		Create(&imp).Error; err != nil {
+		return "", fmt.Errorf("failed to save import of user %d: %w", userID, err)
+	}
+	return token, nil
+}
+
+// TakePendingImport removes the user's pending import with the token and returns the
+// list that was active when the file was sent along with the parsed file. Deleting
+// claims the import, so a second tap, here or on another replica, gets ErrNoPendingImport.
+func (s *Store) TakePendingImport(ctx context.Context, userID int64, token string) (int64, importedList, error) {
+	var imp PendingImport
+	if err := s.db.WithContext(ctx).
+		Where("user_id = ? AND token = ?", userID, token).
+		First(&imp).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, importedList{}, ErrNoPendingImport
+		}
+		return 0, importedList{}, fmt.Errorf("failed to fetch import of user %d: %w", userID, err)
+	}
+
+	res := s.db.WithContext(ctx).
+		Where("id = ? AND token = ?", imp.ID, token).
+		Delete(&PendingImport{})
+	if res.Error != nil {
+		return 0, importedList{}, fmt.Errorf("failed to delete import of user %d: %w", userID, res.Error)
+	}
+	if res.RowsAffected == 0 || time.Now().After(imp.ExpiresAt) {
+		return 0, importedList{}, ErrNoPendingImport
+	}
+
+	imported := importedList{Title: imp.Title}
+	if err := json.Unmarshal([]byte(imp.Items), &imported.Items); err != nil {
+		return 0, importedList{}, fmt.Errorf("failed to decode import of user %d: %w", userID, err)
+	}
+	return imp.ListID, imported, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// refreshDelay collects changes to a list before its messages are edited,
+	// which also keeps edits in one chat below Telegram's limit of one per second
+	refreshDelay = time.Second
+	// refreshEditsPerSecond stays under Telegram's limit of 30 messages per second
+	// across all chats, leaving room for regular replies
+	refreshEditsPerSecond = 20
+)
+
+// listRefresher re-renders the list messages of every member after the list changes,
+// so keyboards in other chats don't go stale
+type listRefresher struct {
+	app      *app
+	bot      *bot.Bot
+	throttle *time.Ticker
+	mu       sync.Mutex
+	pending  map[int64]struct{}
+}
+
+func newListRefresher(a *app, b *bot.Bot) *listRefresher {
+	return &listRefresher{
+		app:      a,
+		bot:      b,
+		throttle: time.NewTicker(time.Second / refreshEditsPerSecond),
+		pending:  make(map[int64]struct{}),
+	}
+}
+
+// ListChanged schedules a refresh of the list's messages
+func (r *listRefresher) ListChanged(listID int64) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.pending[listID]; ok {
+		return
+	}
+	r.pending[listID] = struct{}{}
+	time.AfterFunc(refreshDelay, func() { r.refresh(listID) })
+}
+
+func (r *listRefresher) refresh(listID int64) {
+	r.mu.Lock()
+	delete(r.pending, listID)
+	r.mu.Unlock()
+
+	// The refresh outlives the update that started it
+	ctx := context.Background()
+
+	list, err := r.app.store.GetList(ctx, listID)
+	if err != nil {
+		errorLog.Printf("Failed to get list %d for refresh: %v", listID, err)
+		return
+	}
+
+	msgs, err := r.app.store.ListMessages(ctx, listID)
+	if err != nil {
+		errorLog.Printf("Failed to get messages of list %d: %v", listID, err)
+		return
+	}
+
+	text := fmt.Sprintf("%s:", list.Name)
+	for _, msg := range msgs {
+		kb, err := r.app.listItemsKeyboard(userContext(ctx, r.app.store, msg.UserID), r.bot, list, msg.UserID)
+		if err != nil {
+			errorLog.Printf("Failed to create keyboard for list %d, user %d: %v", listID, msg.UserID, err)
+			continue
+		}
+
+		<-r.throttle.C
+		sent := &models.Message{ID: msg.MessageID, Chat: models.Chat{ID: msg.UserID}}
+		if err := editInlineKeyboard(ctx, r.bot, sent, text, kb); err != nil && messageGone(err) {
+			if err := r.app.store.DeleteListMessage(ctx, msg.UserID, listID); err != nil {
+				errorLog.Printf("Failed to forget message of list %d for user %d: %v", listID, msg.UserID, err)
+			}
+		}
+	}
+}
+
+// messageGone reports whether Telegram refused an edit for good, because the message
+// was deleted or the user blocked the bot, rather than for flood control or network errors
+func messageGone(err error) bool {
+	return strings.Contains(err.Error(), "statusCode 400") || strings.Contains(err.Error(), "statusCode 403")
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"gorm.io/gorm"
+)
+
+// reminderInterval is how often the dispatcher looks for items that came due
+const reminderInterval = time.Minute
+
+// DueRepository manages item due dates and reminders
+type DueRepository interface {
+	DueItems(ctx context.Context, now time.Time) ([]ListItem, error)
+	ClaimReminder(ctx context.Context, itemID int64) (bool, error)
+	OverdueItems(ctx context.Context, userID int64, now time.Time) ([]ListItem, error)
+}
+
+// dueItems selects unchecked items of lists that aren't deleted, due by now
+func dueItems(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Preload("List").
+		Joins("JOIN lists ON lists.id = list_items.list_id AND lists.deleted_at IS NULL").
+		Where("list_items.due_at <= ? AND list_items.done = ?", now, false).
+		Order("list_items.due_at ASC")
+}
+
+// DueItems returns the items due by now whose members weren't reminded yet
+func (s *Store) DueItems(ctx context.Context, now time.Time) ([]ListItem, error) {
+	var items []ListItem
+	if err := dueItems(s.db.WithContext(ctx), now).
+		Where("list_items.reminded = ?", false).
+		Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch due items: %w", err)
+	}
+	return items, nil
+}
+
+// ClaimReminder marks the item reminded. Only one caller succeeds per due date, so with
+// several bots on the same database members get each reminder once. It returns false
+// if someone else claimed the reminder first.
+func (s *Store) ClaimReminder(ctx context.Context, itemID int64) (bool, error) {
+	result := s.db.WithContext(ctx).Model(&ListItem{}).
+		Where("id = ? AND reminded = ?", itemID, false).
+		Update("reminded", true)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to claim reminder of item %d: %w", itemID, result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// OverdueItems returns the user's items that are due by now and not checked off, across all lists
+func (s *Store) OverdueItems(ctx context.Context, userID int64, now time.Time) ([]ListItem, error) {
+	var items []ListItem
+	if err := dueItems(s.db.WithContext(ctx), now).
+		Joins("JOIN list_owners ON list_owners.list_id = list_items.list_id AND list_owners.deleted_at IS NULL").
+		Where("list_owners.user_id = ?", userID).
+		Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch overdue items of user %d: %w", userID, err)
+	}
+	return items, nil
+}
+
+// reminderDispatcher tells list members when their items come due
+type reminderDispatcher struct {
+	store Repository
+	bot   *bot.Bot
+}
+
+func newReminderDispatcher(store Repository, b *bot.Bot) *reminderDispatcher {
+	return &reminderDispatcher{store: store, bot: b}
+}
+
+// run sends reminders every reminderInterval until ctx is done. Items that came
+// due while the bot was down are reminded of on start.
+func (d *reminderDispatcher) run(ctx context.Context) {
+	ticker := time.NewTicker(reminderInterval)
+	defer ticker.Stop()
+
+	d.remindDue(ctx, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			d.remindDue(ctx, now)
+		}
+	}
+}
+
+func (d *reminderDispatcher) remindDue(ctx context.Context, now time.Time) {
+	items, err := d.store.DueItems(ctx, now)
+	if err != nil {
+		errorLog.Printf("Reminders failed to get due items: %v", err)
+		return
+	}
+	if len(items) == 0 {
+		return
+	}
+
+	reminded := 0
+	members := make(map[int64][]ListOwners)
+	for _, item := range items {
+		// Items are claimed first, so a failing send doesn't repeat the reminder every minute
+		claimed, err := d.store.ClaimReminder(ctx, item.ID)
+		if err != nil {
+			errorLog.Printf("Reminders failed to claim item %d: %v", item.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		reminded++
+
+		if _, ok := members[item.ListID]; !ok {
+			if members[item.ListID], err = d.store.ListMembers(ctx, item.ListID); err != nil {
+				errorLog.Printf("Failed to get members of list %d: %v", item.ListID, err)
+			}
+		}
+		for _, member := range members[item.ListID] {
+			memberCtx := userContext(ctx, d.store, member.UserID)
+			sendMessage(ctx, d.bot, member.UserID, tr(memberCtx, MsgItemDue, item.Label(), item.List.Name, item.DueAt.Local().Format(timeLayout)))
+		}
+	}
+	if reminded > 0 {
+		log.Printf("Reminded of %d due items", reminded)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"gorm.io/gorm"
+)
+
+// scheduleHandler shows or sets how the active list resets itself:
+// /schedule, /schedule off, /schedule <rule> [template <name>] [silent]
+func (a *app) scheduleHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.Message == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	words := strings.Fields(update.Message.Text)
+	if words[0] != "/schedule" {
+		sendMessage(ctx, b, userID, tr(ctx, ErrUnknownCommand))
+		return
+	}
+
+	list, err := a.store.GetSelectedList(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
+		return
+	}
+
+	switch {
+	case len(words) == 1:
+		a.showSchedule(ctx, b, userID, list)
+	case len(words) == 2 && words[1] == "off":
+		if err := a.store.DeleteSchedule(ctx, userID, list.ID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				sendMessage(ctx, b, userID, tr(ctx, MsgNoSchedule, list.Name))
+				return
+			}
+			errorLog.Printf("Failed to delete schedule of list %d for user %d: %v", list.ID, userID, err)
+			sendStoreError(ctx, b, userID, err, ErrSetSchedule)
+			return
+		}
+		sendMessage(ctx, b, userID, tr(ctx, MsgScheduleOff, list.Name))
+	default:
+		a.setSchedule(ctx, b, userID, list, words[1:])
+	}
+}
+
+func (a *app) setSchedule(ctx context.Context, b *bot.Bot, userID int64, list List, args []string) {
+	schedule := ListSchedule{ListID: list.ID, Notify: true}
+
+	var ruleWords []string
+	for i := 0; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "silent":
+			schedule.Notify = false
+		case "template":
+			name := strings.Join(args[i+1:], " ")
+			if name == "" {
+				sendMessage(ctx, b, userID, tr(ctx, ErrScheduleArgs))
+				return
+			}
+			template, ok := a.findTemplate(ctx, b, userID, name)
+			if !ok {
+				return
+			}
+			schedule.TemplateID = template.ID
+			i = len(args)
+		default:
+			ruleWords = append(ruleWords, args[i])
+		}
+	}
+
+	rule, err := parseScheduleRule(strings.Join(ruleWords, " "))
+	if err != nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrScheduleArgs))
+		return
+	}
+	now := time.Now()
+	schedule.Rule = rule
+	schedule.Since = now
+	if schedule.NextRun, err = nextScheduleRun(rule, now); err != nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrScheduleArgs))
+		return
+	}
+
+	if _, err := a.store.SetSchedule(ctx, userID, schedule); err != nil {
+		errorLog.Printf("Failed to set schedule of list %d for user %d: %v", list.ID, userID, err)
+		sendStoreError(ctx, b, userID, err, ErrSetSchedule)
+		return
+	}
+	a.showSchedule(ctx, b, userID, list)
+}
+
+func (a *app) showSchedule(ctx context.Context, b *bot.Bot, userID int64, list List) {
+	schedule, err := a.store.Schedule(ctx, userID, list.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		sendMessage(ctx, b, userID, tr(ctx, MsgNoSchedule, list.Name))
+		return
+	}
+	if err != nil {
+		errorLog.Printf("Failed to get schedule of list %d for user %d: %v", list.ID, userID, err)
+		sendStoreError(ctx, b, userID, err, ErrSchedule)
+		return
+	}
+
+	action := tr(ctx, MsgScheduleRestore)
+	if schedule.TemplateID != 0 {
+		action = tr(ctx, MsgScheduleTemplate, a.templateName(ctx, schedule))
+	}
+	if !schedule.Notify {
+		action += tr(ctx, MsgScheduleSilent)
+	}
+	sendMessage(ctx, b, userID, tr(ctx, MsgSchedule, list.Name, schedule.Rule, action, schedule.NextRun.Local().Format(timeLayout)))
+}
+
+// templateName returns the name of the schedule's template, templates belong to whoever set the schedule
+func (a *app) templateName(ctx context.Context, schedule ListSchedule) string {
+	templates, err := a.store.Templates(ctx, schedule.CreatedBy)
+	if err != nil {
+		errorLog.Printf("Failed to get templates of user %d: %v", schedule.CreatedBy, err)
+	}
+	for _, template := range templates {
+		if template.ID == schedule.TemplateID {
+			return template.Name
+		}
+	}
+	return "?"
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// schedulerInterval is how often the scheduler looks for lists due for a reset
+const schedulerInterval = time.Minute
+
+// ListSchedule resets a list whenever its cron rule is due. Without a template the
+// reset unchecks all items and restores the ones deleted since the previous reset,
+// with a template the items are replaced by the template's.
+type ListSchedule struct {
+	ID         int64  `gorm:"primaryKey"`
+	ListID     int64  `gorm:"uniqueIndex"`
+	Rule       string `gorm:"not null"`
+	TemplateID int64  `gorm:"not null;default:0"`
+	Notify     bool   `gorm:"not null"`
+	CreatedBy  int64
+	NextRun    time.Time `gorm:"index"`
+	// Since is when the previous reset happened, items deleted after it are restored
+	Since     time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ScheduleRepository manages list schedules
+type ScheduleRepository interface {
+	SetSchedule(ctx context.Context, userID int64, schedule ListSchedule) (ListSchedule, error)
+	Schedule(ctx context.Context, userID int64, listID int64) (ListSchedule, error)
+	DeleteSchedule(ctx context.Context, userID int64, listID int64) error
+	DueSchedules(ctx context.Context, now time.Time) ([]ListSchedule, error)
+	ClaimSchedule(ctx context.Context, schedule ListSchedule, next time.Time) (bool, error)
+	ResetList(ctx context.Context, schedule ListSchedule, now time.Time) (int, error)
+}
+
+// ErrScheduleDisabled is returned by ResetList when the schedule's creator no longer
+// owns the list. The schedule is deleted instead of resetting the list.
+var ErrScheduleDisabled = errors.New("schedule disabled")
+
+// SetSchedule creates or replaces the schedule of the list, only its owners may do that
+func (s *Store) SetSchedule(ctx context.Context, userID int64, schedule ListSchedule) (ListSchedule, error) {
+	if err := s.CheckRole(ctx, userID, schedule.ListID, RoleOwner); err != nil {
+		return ListSchedule{}, err
+	}
+
+	schedule.ID = 0
+	schedule.CreatedBy = userID
+	if err := s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "list_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"rule", "template_id", "notify", "created_by", "next_run", "since", "updated_at"}),
+		}).
+		Create(&schedule).Error; err != nil {
+		return ListSchedule{}, fmt.Errorf("failed to save schedule of list %d for user %d: %w", schedule.ListID, userID, err)
+	}
+	return schedule, nil
+}
+
+// Schedule returns the schedule of the list, gorm.ErrRecordNotFound if it has none
+func (s *Store) Schedule(ctx context.Context, userID int64, listID int64) (ListSchedule, error) {
+	if err := s.CheckOwner(ctx, userID, listID); err != nil {
+		return ListSchedule{}, err
+	}
+
+	var schedule ListSchedule
+	if err := s.db.WithContext(ctx).Where("list_id = ?", listID).First(&schedule).Error; err != nil {
+		return ListSchedule{}, fmt.Errorf("failed to fetch schedule of list %d: %w", listID, err)
+	}
+	return schedule, nil
+}
+
+func (s *Store) DeleteSchedule(ctx context.Context, userID int64, listID int64) error {
+	if err := s.CheckRole(ctx, userID, listID, RoleOwner); err != nil {
+		return err
+	}
+
+	result := s.db.WithContext(ctx).Where("list_id = ?", listID).Delete(&ListSchedule{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete schedule of list %d: %w", listID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DueSchedules returns the schedules of lists that aren't deleted and are due by now
+func (s *Store) DueSchedules(ctx context.Context, now time.Time) ([]ListSchedule, error) {
+	var schedules []ListSchedule
+	if err := s.db.WithContext(ctx).
+		Joins("JOIN lists ON lists.id = list_schedules.list_id AND lists.deleted_at IS NULL").
+		Where("list_schedules.next_run <= ?", now).
+		Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch due schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// ResetList resets the list as its schedule says and returns how many items it changed.
+// The reset acts on behalf of the schedule's creator, if they are no longer an owner
+// of the list the schedule is deleted and ErrScheduleDisabled returned.
+func (s *Store) ResetList(ctx context.Context, schedule ListSchedule, now time.Time) (int, error) {
+	tx := s.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := checkRole(tx, schedule.CreatedBy, schedule.ListID, RoleOwner); err != nil {
+		if !errors.Is(err, ErrForbidden) && !errors.Is(err, gorm.ErrRecordNotFound) {
+			tx.Rollback()
+			return 0, err
+		}
+		if err := tx.Where("id = ?", schedule.ID).Delete(&ListSchedule{}).Error; err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to delete schedule %d: %w", schedule.ID, err)
+		}
+		if err := tx.Commit().Error; err != nil {
+			return 0, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return 0, fmt.Errorf("schedule %d of list %d: %w: %w", schedule.ID, schedule.ListID, ErrScheduleDisabled, err)
+	}
+
+	var count int
+	var err error
+	if schedule.TemplateID != 0 {
+		count, err = recreateItems(tx, schedule)
+	} else {
+		count, err = restoreItems(tx, schedule)
+	}
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := tx.Model(&ListSchedule{}).Where("id = ?", schedule.ID).Update("since", now).Error; err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to update schedule %d: %w", schedule.ID, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return count, nil
+}
+
+// restoreItems unchecks the list's items and restores the ones deleted since the previous reset
+func restoreItems(tx *gorm.DB, schedule ListSchedule) (int, error) {
+	result := tx.Model(&ListItem{}).
+		Where("list_id = ? AND done = ?", schedule.ListID, true).
+		Update("done", false)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to uncheck items of list %d: %w", schedule.ListID, result.Error)
+	}
+
+	var deleted []ListItem
+	if err := tx.Unscoped().
+		Where("list_id = ? AND deleted_at IS NOT NULL AND deleted_at >= ?", schedule.ListID, schedule.Since).
+		Order("item_order ASC").
+		Find(&deleted).Error; err != nil {
+		return 0, fmt.Errorf("failed to fetch deleted items of list %d: %w", schedule.ListID, err)
+	}
+	for _, item := range deleted {
+		if err := restoreItem(tx, item); err != nil {
+			return 0, fmt.Errorf("failed to restore item %d: %w", item.ID, err)
+		}
+	}
+	return int(result.RowsAffected) + len(deleted), nil
+}
+
+// recreateItems replaces the list's items with the items of the schedule's template
+func recreateItems(tx *gorm.DB, schedule ListSchedule) (int, error) {
+	var template ListTemplate
+	if err := tx.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("item_order ASC") }).
+		Where("id = ? AND user_id = ?", schedule.TemplateID, schedule.CreatedBy).
+		First(&template).Error; err != nil {
+		return 0, fmt.Errorf("failed to fetch template %d of schedule %d: %w", schedule.TemplateID, schedule.ID, err)
+	}
+
+	var itemIDs []int64
+	if err := tx.Model(&ListItem{}).Where("list_id = ?", schedule.ListID).Pluck("id", &itemIDs).Error; err != nil {
+		return 0, fmt.Errorf("failed to fetch items of list %d: %w", schedule.ListID, err)
+	}
+	if len(itemIDs) > 0 {
+		if err := deleteItems(tx, schedule.CreatedBy, itemIDs...); err != nil {
+			return 0, fmt.Errorf("failed to delete items of list %d: %w", schedule.ListID, err)
+		}
+	}
+
+	if len(template.Items) == 0 {
+		return 0, nil
+	}
+	items := make([]ListItem, len(template.Items))
+	for i, item := range template.Items {
+		items[i] = ListItem{Name: item.Name, Quantity: item.Quantity, Unit: item.Unit}
+	}
+	if _, err := appendItems(tx, schedule.CreatedBy, schedule.ListID, items); err != nil {
+		return 0, err
+	}
+	return len(items), nil
+}
+
+// ClaimSchedule moves the schedule's next run from the one it was fetched with to next.
+// Only one caller succeeds per run, so with several bots on the same database each
+// due reset happens once. It returns false if someone else claimed the run first.
+func (s *Store) ClaimSchedule(ctx context.Context, schedule ListSchedule, next time.Time) (bool, error) {
+	result := s.db.WithContext(ctx).Model(&ListSchedule{}).
+		Where("id = ? AND next_run = ?", schedule.ID, schedule.NextRun).
+		Update("next_run", next)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to claim schedule %d: %w", schedule.ID, result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// scheduler resets lists on their schedules and tells their members about it
+type scheduler struct {
+	store     Repository
+	bot       *bot.Bot
+	refresher *listRefresher
+}
+
+func newScheduler(store Repository, b *bot.Bot, refresher *listRefresher) *scheduler {
+	return &scheduler{store: store, bot: b, refresher: refresher}
+}
+
+// run resets due lists every schedulerInterval until ctx is done. Resets missed
+// while the bot was down happen once on start.
+func (s *scheduler) run(ctx context.Context) {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	s.resetDue(ctx, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.resetDue(ctx, now)
+		}
+	}
+}
+
+func (s *scheduler) resetDue(ctx context.Context, now time.Time) {
+	schedules, err := s.store.DueSchedules(ctx, now)
+	if err != nil {
+		errorLog.Printf("Scheduler failed to get due schedules: %v", err)
+		return
+	}
+
+	for _, schedule := range schedules {
+		cron, err := parseCron(schedule.Rule)
+		if err != nil {
+			errorLog.Printf("Schedule %d of list %d has an invalid rule: %v", schedule.ID, schedule.ListID, err)
+			continue
+		}
+
+		// A failed reset waits for the next run instead of being retried every minute
+		claimed, err := s.store.ClaimSchedule(ctx, schedule, cron.Next(now))
+		if err != nil {
+			errorLog.Printf("Scheduler failed to plan schedule %d: %v", schedule.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		count, err := s.store.ResetList(ctx, schedule, now)
+		if errors.Is(err, ErrScheduleDisabled) {
+			log.Printf("Scheduler disabled schedule %d: %v", schedule.ID, err)
+			continue
+		}
+		if err != nil {
+			errorLog.Printf("Scheduler failed to reset list %d: %v", schedule.ListID, err)
+			continue
+		}
+		log.Printf("Scheduler reset list %d, %d items changed", schedule.ListID, count)
+		s.refresher.ListChanged(schedule.ListID)
+
+		if schedule.Notify {
+			s.notifyMembers(ctx, schedule.ListID)
+		}
+	}
+}
+
+// notifyMembers tells every member of the list that it was reset
+func (s *scheduler) notifyMembers(ctx context.Context, listID int64) {
+	list, err := s.store.GetList(ctx, listID)
+	if err != nil {
+		errorLog.Printf("Failed to get list %d for reset notification: %v", listID, err)
+		return
+	}
+
+	members, err := s.store.ListMembers(ctx, listID)
+	if err != nil {
+		errorLog.Printf("Failed to get members of list %d: %v", listID, err)
+		return
+	}
+	for _, member := range members {
+		sendMessage(ctx, s.bot, member.UserID, tr(userContext(ctx, s.store, member.UserID), MsgListReset, list.Name))
+	}
+}
+
+// nextScheduleRun returns when the rule is due next after now, or an error if it never is
+func nextScheduleRun(rule string, now time.Time) (time.Time, error) {
+	cron, err := parseCron(rule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next := cron.Next(now)
+	if next.IsZero() {
+		return time.Time{}, errors.New("schedule rule is never due")
+	}
+	return next, nil
+}
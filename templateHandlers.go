@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// cloneHandler copies the active list into a new one: /clone [name]
+func (a *app) cloneHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.Message == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	words := strings.Fields(update.Message.Text)
+	if words[0] != "/clone" {
+		sendMessage(ctx, b, userID, tr(ctx, ErrUnknownCommand))
+		return
+	}
+
+	list, err := a.store.GetSelectedList(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
+		return
+	}
+
+	name := strings.Join(words[1:], " ")
+	if name == "" {
+		name = tr(ctx, MsgCloneName, list.Name)
+	}
+	lists, err := a.store.UserLists(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get lists of user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrCloneList))
+		return
+	}
+	name = uniqueListName(lists, name)
+
+	clone, err := a.store.CloneList(ctx, userID, list.ID, name)
+	if err != nil {
+		errorLog.Printf("Failed to clone list %d for user %d: %v", list.ID, userID, err)
+		sendStoreError(ctx, b, userID, err, ErrCloneList)
+		return
+	}
+
+	sendMessage(ctx, b, userID, tr(ctx, MsgListCloned, list.Name, clone.Name))
+	a.showList(ctx, b, update, userID, clone)
+}
+
+// templateHandler manages templates: /template [save|use|delete] [name]
+func (a *app) templateHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.Message == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	words := strings.Fields(update.Message.Text)
+	if words[0] != "/template" {
+		sendMessage(ctx, b, userID, tr(ctx, ErrUnknownCommand))
+		return
+	}
+	if len(words) == 1 {
+		a.showTemplates(ctx, b, userID)
+		return
+	}
+	name := strings.Join(words[2:], " ")
+
+	switch words[1] {
+	case "save":
+		a.saveTemplate(ctx, b, userID, name)
+	case "use":
+		if name == "" {
+			a.showTemplates(ctx, b, userID)
+			return
+		}
+		if template, ok := a.findTemplate(ctx, b, userID, name); ok {
+			a.useTemplate(ctx, b, update, userID, template)
+		}
+	case "delete":
+		if name == "" {
+			sendMessage(ctx, b, userID, tr(ctx, ErrTemplateArgs))
+			return
+		}
+		template, ok := a.findTemplate(ctx, b, userID, name)
+		if !ok {
+			return
+		}
+		if _, err := a.store.DeleteTemplate(ctx, userID, template.ID); err != nil {
+			errorLog.Printf("Failed to delete template %d of user %d: %v", template.ID, userID, err)
+			sendMessage(ctx, b, userID, tr(ctx, ErrDeleteTemplate))
+			return
+		}
+		sendMessage(ctx, b, userID, tr(ctx, MsgTemplateDeleted, template.Name))
+	default:
+		sendMessage(ctx, b, userID, tr(ctx, ErrTemplateArgs))
+	}
+}
+
+// saveTemplate saves the active list as a template, named after the list unless name is given
+func (a *app) saveTemplate(ctx context.Context, b *bot.Bot, userID int64, name string) {
+	list, err := a.store.GetSelectedList(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
+		return
+	}
+	if name == "" {
+		name = list.Name
+	}
+
+	template, err := a.store.SaveTemplate(ctx, userID, list.ID, name)
+	if err != nil {
+		errorLog.Printf("Failed to save list %d as template '%s' for user %d: %v", list.ID, name, userID, err)
+		sendStoreError(ctx, b, userID, err, ErrSaveTemplate)
+		return
+	}
+	sendMessage(ctx, b, userID, tr(ctx, MsgTemplateSaved, template.Name, len(template.Items)))
+}
+
+// findTemplate looks up the user's template by name, ignoring case and spacing
+func (a *app) findTemplate(ctx context.Context, b *bot.Bot, userID int64, name string) (ListTemplate, bool) {
+	templates, err := a.store.Templates(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get templates of user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrTemplates))
+		return ListTemplate{}, false
+	}
+	for _, template := range templates {
+		if normalizeName(template.Name) == normalizeName(name) {
+			return template, true
+		}
+	}
+	sendMessage(ctx, b, userID, tr(ctx, ErrTemplateNotFound, name))
+	return ListTemplate{}, false
+}
+
+// showTemplates lists the user's templates with a button to start a list from each
+func (a *app) showTemplates(ctx context.Context, b *bot.Bot, userID int64) {
+	templates, err := a.store.Templates(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get templates of user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrTemplates))
+		return
+	}
+	if len(templates) == 0 {
+		sendMessage(ctx, b, userID, tr(ctx, MsgNoTemplates))
+		return
+	}
+
+	kb := &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{}}
+	for _, template := range templates {
+		kb.InlineKeyboard = append(kb.InlineKeyboard, []models.InlineKeyboardButton{{
+			Text:         fmt.Sprintf("%s (%d)", template.Name, len(template.Items)),
+			CallbackData: fmt.Sprintf("templateUse_%d", template.ID),
+		}})
+	}
+	sendInlineKeyboard(ctx, b, userID, tr(ctx, MsgTemplates), kb)
+}
+
+// useTemplate starts a new list from the template and shows it
+func (a *app) useTemplate(ctx context.Context, b *bot.Bot, update *models.Update, userID int64, template ListTemplate) {
+	lists, err := a.store.UserLists(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get lists of user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrUseTemplate))
+		return
+	}
+
+	list, err := a.store.UseTemplate(ctx, userID, template.ID, uniqueListName(lists, template.Name))
+	if err != nil {
+		errorLog.Printf("Failed to use template %d for user %d: %v", template.ID, userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrUseTemplate))
+		return
+	}
+
+	sendMessage(ctx, b, userID, tr(ctx, MsgTemplateUsed, list.Name))
+	a.showList(ctx, b, update, userID, list)
+}
+
+func (a *app) onTemplateUse(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if err := answerCallback(ctx, b, update); err != nil {
+		return
+	}
+
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.CallbackQuery == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	templateID, err := parseInt64(strings.TrimPrefix(update.CallbackQuery.Data, "templateUse_"))
+	if err != nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidID))
+		return
+	}
+
+	templates, err := a.store.Templates(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get templates of user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrTemplates))
+		return
+	}
+	for _, template := range templates {
+		if template.ID == templateID {
+			a.useTemplate(ctx, b, update, userID, template)
+			return
+		}
+	}
+	// The template was deleted since the keyboard was sent
+	sendMessage(ctx, b, userID, tr(ctx, ErrUseTemplate))
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ListTemplate is a set of items a user keeps to start new lists from
+type ListTemplate struct {
+	ID        int64          `gorm:"primaryKey"`
+	UserID    int64          `gorm:"uniqueIndex:idx_list_templates_user_name"`
+	Name      string         `gorm:"not null;uniqueIndex:idx_list_templates_user_name"`
+	Items     []TemplateItem `gorm:"foreignKey:TemplateID"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TemplateItem is an item of a template, kept in the order it had in the list
+type TemplateItem struct {
+	ID         int64 `gorm:"primaryKey"`
+	TemplateID int64 `gorm:"index"`
+	Name       string
+	Quantity   float64
+	Unit       string
+	Item_order int
+}
+
+// TemplateRepository manages the templates of a user
+type TemplateRepository interface {
+	SaveTemplate(ctx context.Context, userID int64, listID int64, name string) (ListTemplate, error)
+	Templates(ctx context.Context, userID int64) ([]ListTemplate, error)
+	UseTemplate(ctx context.Context, userID int64, templateID int64, listName string) (List, error)
+	DeleteTemplate(ctx context.Context, userID int64, templateID int64) (ListTemplate, error)
+}
+
+// SaveTemplate stores the items of the list as the user's template called name,
+// replacing the items of a template with that name if there is one already.
+// Check marks aren't saved, lists made from a template start unchecked.
+func (s *Store) SaveTemplate(ctx context.Context, userID int64, listID int64, name string) (ListTemplate, error) {
+	if name == "" {
+		return ListTemplate{}, errors.New("template name cannot be empty")
+	}
+
+	if err := s.CheckOwner(ctx, userID, listID); err != nil {
+		return ListTemplate{}, err
+	}
+
+	tx := s.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var items []ListItem
+	if err := tx.Where("list_id = ?", listID).Order("item_order ASC").Find(&items).Error; err != nil {
+		tx.Rollback()
+		return ListTemplate{}, fmt.Errorf("failed to fetch items for list %d: %w", listID, err)
+	}
+
+	var template ListTemplate
+	err := tx.Where("user_id = ? AND name = ?", userID, name).First(&template).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		template = ListTemplate{UserID: userID, Name: name}
+		if err := tx.Create(&template).Error; err != nil {
+			tx.Rollback()
+			return ListTemplate{}, fmt.Errorf("failed to create template '%s' for user %d: %w", name, userID, err)
+		}
+	case err != nil:
+		tx.Rollback()
+		return ListTemplate{}, fmt.Errorf("failed to fetch template '%s' for user %d: %w", name, userID, err)
+	default:
+		if err := tx.Where("template_id = ?", template.ID).Delete(&TemplateItem{}).Error; err != nil {
+			tx.Rollback()
+			return ListTemplate{}, fmt.Errorf("failed to clear template %d: %w", template.ID, err)
+		}
+		if err := tx.Model(&template).Update("updated_at", time.Now()).Error; err != nil {
+			tx.Rollback()
+			return ListTemplate{}, fmt.Errorf("failed to update template %d: %w", template.ID, err)
+		}
+	}
+
+	template.Items = make([]TemplateItem, len(items))
+	for i, item := range items {
+		template.Items[i] = TemplateItem{
+			TemplateID: template.ID,
+			Name:       item.Name,
+			Quantity:   item.Quantity,
+			Unit:       item.Unit,
+			Item_order: i + 1,
+		}
+	}
+	if len(template.Items) > 0 {
+		if err := tx.Create(&template.Items).Error; err != nil {
+			tx.Rollback()
+			return ListTemplate{}, fmt.Errorf("failed to save items of template %d: %w", template.ID, err)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ListTemplate{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return template, nil
+}
+
+// Templates returns the user's templates by name, with their items
+func (s *Store) Templates(ctx context.Context, userID int64) ([]ListTemplate, error) {
+	var templates []ListTemplate
+	if err := s.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("item_order ASC") }).
+		Where("user_id = ?", userID).
+		Order("name ASC").
+		Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch templates of user %d: %w", userID, err)
+	}
+	return templates, nil
+}
+
+// UseTemplate creates a list called listName with the items of the template,
+// owned by the user and made the active list
+func (s *Store) UseTemplate(ctx context.Context, userID int64, templateID int64, listName string) (List, error) {
+	var template ListTemplate
+	if err := s.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("item_order ASC") }).
+		Where("id = ? AND user_id = ?", templateID, userID).
+		First(&template).Error; err != nil {
+		return List{}, fmt.Errorf("failed to fetch template %d of user %d: %w", templateID, userID, err)
+	}
+
+	items := make([]ListItem, len(template.Items))
+	for i, item := range template.Items {
+		items[i] = ListItem{Name: item.Name, Quantity: item.Quantity, Unit: item.Unit}
+	}
+	return s.createListWithItems(ctx, userID, listName, items)
+}
+
+// DeleteTemplate removes the user's template along with its items
+func (s *Store) DeleteTemplate(ctx context.Context, userID int64, templateID int64) (ListTemplate, error) {
+	tx := s.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var template ListTemplate
+	if err := tx.Where("id = ? AND user_id = ?", templateID, userID).First(&template).Error; err != nil {
+		tx.Rollback()
+		return ListTemplate{}, fmt.Errorf("failed to fetch template %d of user %d: %w", templateID, userID, err)
+	}
+
+	if err := tx.Where("template_id = ?", template.ID).Delete(&TemplateItem{}).Error; err != nil {
+		tx.Rollback()
+		return ListTemplate{}, fmt.Errorf("failed to delete items of template %d: %w", template.ID, err)
+	}
+	if err := tx.Delete(&template).Error; err != nil {
+		tx.Rollback()
+		return ListTemplate{}, fmt.Errorf("failed to delete template %d: %w", template.ID, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ListTemplate{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return template, nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const trashPageSize = 10
+
+// trashPage renders a page of the list's deleted items with restore buttons
+func (a *app) trashPage(ctx context.Context, b *bot.Bot, list List, userID int64, page int) (string, *models.InlineKeyboardMarkup, error) {
+	// Keep the offset from overflowing, such a page is past the end anyway
+	if page > math.MaxInt/trashPageSize {
+		page = math.MaxInt / trashPageSize
+	}
+
+	items, total, err := a.store.TrashItems(ctx, userID, list.ID, page*trashPageSize, trashPageSize)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// The last page may have emptied after a restore, show the one that is last now
+	if last := int((total - 1) / trashPageSize); len(items) == 0 && total > 0 && page > last {
+		page = last
+		items, total, err = a.store.TrashItems(ctx, userID, list.ID, page*trashPageSize, trashPageSize)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	if len(items) == 0 {
+		return tr(ctx, MsgTrashEmpty, list.Name), nil, nil
+	}
+
+	first := page*trashPageSize + 1
+	lines := []string{tr(ctx, MsgTrash, list.Name, first, first+len(items)-1, total)}
+	kb := &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{}}
+	names := make(map[int64]string)
+	var row []models.InlineKeyboardButton
+	for i, item := range items {
+		deleter := tr(ctx, MsgUnknownUser)
+		if item.DeletedBy != 0 {
+			if _, ok := names[item.DeletedBy]; !ok {
+				names[item.DeletedBy] = userDisplayName(ctx, b, item.DeletedBy)
+			}
+			deleter = names[item.DeletedBy]
+		}
+		n := first + i
+		lines = append(lines, fmt.Sprintf("%d. %s — %s, %s", n, item.Label(), deleter, item.DeletedAt.Time.Format(timeLayout)))
+
+		row = append(row, models.InlineKeyboardButton{
+			Text:         fmt.Sprintf("♻️ %d", n),
+			CallbackData: fmt.Sprintf("trashRestore_%d_%d_%d", list.ID, item.ID, page),
+		})
+		if len(row) == 5 {
+			kb.InlineKeyboard = append(kb.InlineKeyboard, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		kb.InlineKeyboard = append(kb.InlineKeyboard, row)
+	}
+
+	var nav []models.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, models.InlineKeyboardButton{Text: "◀️", CallbackData: fmt.Sprintf("trashPage_%d_%d", list.ID, page-1)})
+	}
+	if int64(first+len(items)-1) < total {
+		nav = append(nav, models.InlineKeyboardButton{Text: "▶️", CallbackData: fmt.Sprintf("trashPage_%d_%d", list.ID, page+1)})
+	}
+	if len(nav) > 0 {
+		kb.InlineKeyboard = append(kb.InlineKeyboard, nav)
+	}
+
+	return strings.Join(lines, "\n"), kb, nil
+}
+
+// showTrash edits the trash message the callback came from, or sends a new one
+func (a *app) showTrash(ctx context.Context, b *bot.Bot, update *models.Update, userID int64, list List, page int) {
+	text, kb, err := a.trashPage(ctx, b, list, userID, page)
+	if err != nil {
+		errorLog.Printf("Failed to get trash of list %d for user %d: %v", list.ID, userID, err)
+		sendStoreError(ctx, b, userID, err, ErrTrash)
+		return
+	}
+
+	if cq := update.CallbackQuery; cq != nil && cq.Message != nil {
+		if kb == nil {
+			if err := editMessage(ctx, b, cq.Message, text); err == nil {
+				return
+			}
+		} else if err := editInlineKeyboard(ctx, b, cq.Message, text, kb); err == nil {
+			return
+		}
+	}
+
+	if kb == nil {
+		sendMessage(ctx, b, userID, text)
+		return
+	}
+	sendInlineKeyboard(ctx, b, userID, text, kb)
+}
+
+func (a *app) trashHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	list, err := a.store.GetSelectedList(ctx, userID)
+	if err != nil {
+		errorLog.Printf("Failed to get selected list for user %d: %v", userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrNoActiveList))
+		return
+	}
+
+	a.showTrash(ctx, b, update, userID, list, 0)
+}
+
+func (a *app) onTrashPage(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if err := answerCallback(ctx, b, update); err != nil {
+		return
+	}
+
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.CallbackQuery == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	parts := strings.Split(update.CallbackQuery.Data, "_")
+	if len(parts) != 3 {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	listID, err := parseInt64(parts[1])
+	if err != nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidID))
+		return
+	}
+
+	page, err := parseInt64(parts[2])
+	if err != nil || page < 0 {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidID))
+		return
+	}
+
+	list, err := a.store.GetList(ctx, listID)
+	if err != nil {
+		errorLog.Printf("Failed to get list %d for user %d: %v", listID, userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrTrash))
+		return
+	}
+
+	a.showTrash(ctx, b, update, userID, list, int(page))
+}
+
+func (a *app) onTrashRestore(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if err := answerCallback(ctx, b, update); err != nil {
+		return
+	}
+
+	userID, err := getUserID(update)
+	if err != nil {
+		errorLog.Printf("Failed to get user ID: %v", err)
+		return
+	}
+
+	if update.CallbackQuery == nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	parts := strings.Split(update.CallbackQuery.Data, "_")
+	if len(parts) != 4 {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidCallback))
+		return
+	}
+
+	listID, err := parseInt64(parts[1])
+	if err != nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidID))
+		return
+	}
+
+	itemID, err := parseInt64(parts[2])
+	if err != nil {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidID))
+		return
+	}
+
+	page, err := parseInt64(parts[3])
+	if err != nil || page < 0 {
+		sendMessage(ctx, b, userID, tr(ctx, ErrInvalidID))
+		return
+	}
+
+	_, err = a.store.RestoreItem(ctx, userID, listID, itemID)
+	switch {
+	case errors.Is(err, ErrItemNotFound):
+		// An item restored from another trash message only needs the page redrawn
+	case err != nil:
+		errorLog.Printf("Failed to restore item %d of list %d for user %d: %v", itemID, listID, userID, err)
+		sendStoreError(ctx, b, userID, err, ErrRestoreItem)
+		return
+	default:
+		a.refresher.ListChanged(listID)
+	}
+
+	list, err := a.store.GetList(ctx, listID)
+	if err != nil {
+		errorLog.Printf("Failed to get list %d for user %d: %v", listID, userID, err)
+		sendMessage(ctx, b, userID, tr(ctx, ErrTrash))
+		return
+	}
+
+	a.showTrash(ctx, b, update, userID, list, int(page))
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// trashRepository serves deleted items from memory and counts the queries
+type trashRepository struct {
+	stubRepository
+	trash   []ListItem
+	queries int
+}
+
+func (r *trashRepository) TrashItems(ctx context.Context, userID int64, listID int64, offset int, limit int) ([]ListItem, int64, error) {
+	r.queries++
+	if offset >= len(r.trash) {
+		return nil, int64(len(r.trash)), nil
+	}
+	end := offset + limit
+	if end > len(r.trash) {
+		end = len(r.trash)
+	}
+	return r.trash[offset:end], int64(len(r.trash)), nil
+}
+
+func TestTrashPagePastTheEnd(t *testing.T) {
+	deletedAt := gorm.DeletedAt{Time: time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local), Valid: true}
+	store := &trashRepository{}
+	for i := 0; i < 25; i++ {
+		item := ListItem{ID: int64(i + 1), Name: "milk"}
+		item.DeletedAt = deletedAt
+		store.trash = append(store.trash, item)
+	}
+	a := newApp(store)
+	list := List{ID: 7, Name: "Groceries"}
+
+	tests := []struct {
+		page  int
+		first string
+	}{
+		{page: 1, first: "11. milk"},
+		{page: 3, first: "21. milk"},
+		{page: 1000000000, first: "21. milk"},
+		{page: int(^uint(0) >> 1), first: "21. milk"},
+	}
+	for _, tt := range tests {
+		store.queries = 0
+		text, kb, err := a.trashPage(context.Background(), nil, list, 1, tt.page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(text, "\n")
+		if kb == nil || len(lines) < 2 || !strings.HasPrefix(lines[1], tt.first) {
+			t.Errorf("page %d starts with %q, want %q", tt.page, lines, tt.first)
+		}
+		if store.queries > 2 {
+			t.Errorf("page %d took %d queries", tt.page, store.queries)
+		}
+	}
+
+	store.trash = nil
+	if text, kb, err := a.trashPage(context.Background(), nil, list, 1, 5); err != nil || kb != nil || text != tr(context.Background(), MsgTrashEmpty, list.Name) {
+		t.Errorf("empty trash = %q, %v, %v", text, kb, err)
+	}
+}
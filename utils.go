@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
@@ -11,37 +12,200 @@ import (
 	"github.com/go-telegram/bot/models"
 )
 
-// Error messages
+// timeLayout formats dates and times shown to users
+const timeLayout = "02.01.2006 15:04"
+
+// Error messages, the texts are in the catalogs, see tr
 const (
-	ErrInvalidCallback  = "Неверные данные команды"
-	ErrNoActiveList     = "Не удалось получить активный список"
-	ErrCreateMenu       = "Не удалось создать меню"
-	ErrInvalidID        = "Неверный ID"
-	ErrDeleteItem       = "Не удалось удалить элемент"
-	ErrRestoreItem      = "Не удалось восстановить элемент"
-	ErrNoItemsToRestore = "Нет удалённых элементов для восстановления"
-	ErrSelectList       = "Не удалось выбрать список"
-	ErrCreateList       = "Не удалось создать список"
-	ErrShareList        = "Не удалось поделиться списком"
-	ErrInvalidUserID    = "Укажите действительный ID пользователя"
-	ErrShareWithSelf    = "Нельзя поделиться списком с самим собой"
-	ErrUnknownCommand   = "Неизвестная команда"
-	ErrAddItem          = "Не удалось добавить элемент"
-	ErrRestoreAllItems  = "Не удалось восстановить все элементы"
+	ErrInvalidCallback  msgKey = "ErrInvalidCallback"
+	ErrNoActiveList     msgKey = "ErrNoActiveList"
+	ErrCreateMenu       msgKey = "ErrCreateMenu"
+	ErrInvalidID        msgKey = "ErrInvalidID"
+	ErrDeleteItem       msgKey = "ErrDeleteItem"
+	ErrRestoreItem      msgKey = "ErrRestoreItem"
+	ErrMarkItem         msgKey = "ErrMarkItem"
+	ErrClearDone        msgKey = "ErrClearDone"
+	ErrEditItem         msgKey = "ErrEditItem"
+	ErrEditArgs         msgKey = "ErrEditArgs"
+	ErrItemNumber       msgKey = "ErrItemNumber"
+	ErrResolveDuplicate msgKey = "ErrResolveDuplicate"
+	ErrDuplicateGone    msgKey = "ErrDuplicateGone"
+	ErrTrash            msgKey = "ErrTrash"
+	ErrRetentionArgs    msgKey = "ErrRetentionArgs"
+	ErrSetRetention     msgKey = "ErrSetRetention"
+	ErrNoItemsToRestore msgKey = "ErrNoItemsToRestore"
+	ErrSelectList       msgKey = "ErrSelectList"
+	ErrCreateList       msgKey = "ErrCreateList"
+	ErrShareList        msgKey = "ErrShareList"
+	ErrInvalidUserID    msgKey = "ErrInvalidUserID"
+	ErrShareWithSelf    msgKey = "ErrShareWithSelf"
+	ErrUnknownCommand   msgKey = "ErrUnknownCommand"
+	ErrAddItem          msgKey = "ErrAddItem"
+	ErrRestoreAllItems  msgKey = "ErrRestoreAllItems"
+	ErrRenameList       msgKey = "ErrRenameList"
+	ErrDeleteList       msgKey = "ErrDeleteList"
+	ErrRestoreList      msgKey = "ErrRestoreList"
+	ErrNoDeletedLists   msgKey = "ErrNoDeletedLists"
+	ErrLeaveList        msgKey = "ErrLeaveList"
+	ErrUnshareList      msgKey = "ErrUnshareList"
+	ErrListMembers      msgKey = "ErrListMembers"
+	ErrNoPermission     msgKey = "ErrNoPermission"
+	ErrInvalidRole      msgKey = "ErrInvalidRole"
+	ErrCreateInvite     msgKey = "ErrCreateInvite"
+	ErrInviteArgs       msgKey = "ErrInviteArgs"
+	ErrInviteExpired    msgKey = "ErrInviteExpired"
+	ErrListInvites      msgKey = "ErrListInvites"
+	ErrRevokeInvite     msgKey = "ErrRevokeInvite"
+	ErrNotifyArgs       msgKey = "ErrNotifyArgs"
+	ErrCommasArgs       msgKey = "ErrCommasArgs"
+	ErrSaveSettings     msgKey = "ErrSaveSettings"
+	ErrExportArgs       msgKey = "ErrExportArgs"
+	ErrExportList       msgKey = "ErrExportList"
+	ErrImportFile       msgKey = "ErrImportFile"
+	ErrImportFormat     msgKey = "ErrImportFormat"
+	ErrImportTooLarge   msgKey = "ErrImportTooLarge"
+	ErrImportEmpty      msgKey = "ErrImportEmpty"
+	ErrImportExpired    msgKey = "ErrImportExpired"
+	ErrImportList       msgKey = "ErrImportList"
+	ErrCloneList        msgKey = "ErrCloneList"
+	ErrTemplateArgs     msgKey = "ErrTemplateArgs"
+	ErrTemplates        msgKey = "ErrTemplates"
+	ErrTemplateNotFound msgKey = "ErrTemplateNotFound"
+	ErrSaveTemplate     msgKey = "ErrSaveTemplate"
+	ErrUseTemplate      msgKey = "ErrUseTemplate"
+	ErrDeleteTemplate   msgKey = "ErrDeleteTemplate"
+	ErrScheduleArgs     msgKey = "ErrScheduleArgs"
+	ErrSchedule         msgKey = "ErrSchedule"
+	ErrSetSchedule      msgKey = "ErrSetSchedule"
+	ErrOverdue          msgKey = "ErrOverdue"
+	ErrWebAppURL        msgKey = "ErrWebAppURL"
+	ErrLangArgs         msgKey = "ErrLangArgs"
 )
 
 // Messages
 const (
-	MsgListShared        = "Поделились списком"
-	MsgItemAdded         = "Добавлено: %s"
-	MsgListCreated       = "Создали список '%s' и сделали его активным"
-	MsgSelectList        = "Выберите список:"
-	MsgCreateNewList     = "Используйте /new <Название> для создания нового списка"
-	MsgUndoConfirmFormat = "Вы уверены, что хотите восстановить %d удалённых элементов текущего списка? Будут восстановлены только элементы, созданные вами."
-	MsgUndoCancelled     = "Восстановление отменено"
-	MsgUndoAllSuccess    = "Все удалённые элементы восстановлены"
+	MsgListShared        msgKey = "MsgListShared"
+	MsgItemAdded         msgKey = "MsgItemAdded"
+	MsgItemsAdded        msgKey = "MsgItemsAdded"
+	MsgListCreated       msgKey = "MsgListCreated"
+	MsgSelectList        msgKey = "MsgSelectList"
+	MsgCreateNewList     msgKey = "MsgCreateNewList"
+	MsgUndoConfirmFormat msgKey = "MsgUndoConfirmFormat"
+	MsgUndoCancelled     msgKey = "MsgUndoCancelled"
+	MsgUndoAllSuccess    msgKey = "MsgUndoAllSuccess"
+	MsgListRenamed       msgKey = "MsgListRenamed"
+	MsgDeleteListConfirm msgKey = "MsgDeleteListConfirm"
+	MsgListDeleted       msgKey = "MsgListDeleted"
+	MsgDeleteCancelled   msgKey = "MsgDeleteCancelled"
+	MsgSelectDeletedList msgKey = "MsgSelectDeletedList"
+	MsgListRestored      msgKey = "MsgListRestored"
+	MsgListLeft          msgKey = "MsgListLeft"
+	MsgListArchived      msgKey = "MsgListArchived"
+	MsgListMembers       msgKey = "MsgListMembers"
+	MsgMemberRemoved     msgKey = "MsgMemberRemoved"
+	MsgAccessRevoked     msgKey = "MsgAccessRevoked"
+	MsgInviteCreated     msgKey = "MsgInviteCreated"
+	MsgInviteSingleUse   msgKey = "MsgInviteSingleUse"
+	MsgInviteMultiUse    msgKey = "MsgInviteMultiUse"
+	MsgInvites           msgKey = "MsgInvites"
+	MsgNoInvites         msgKey = "MsgNoInvites"
+	MsgInviteRevoked     msgKey = "MsgInviteRevoked"
+	MsgInviteAccepted    msgKey = "MsgInviteAccepted"
+	MsgInviteJoined      msgKey = "MsgInviteJoined"
+	MsgNotifyOn          msgKey = "MsgNotifyOn"
+	MsgNotifyOff         msgKey = "MsgNotifyOff"
+	MsgNotifyStatus      msgKey = "MsgNotifyStatus"
+	MsgCommasOn          msgKey = "MsgCommasOn"
+	MsgCommasOff         msgKey = "MsgCommasOff"
+	MsgListMuted         msgKey = "MsgListMuted"
+	MsgListUnmuted       msgKey = "MsgListUnmuted"
+	MsgListChanged       msgKey = "MsgListChanged"
+	MsgItemRenamed       msgKey = "MsgItemRenamed"
+	MsgDuplicateFound    msgKey = "MsgDuplicateFound"
+	MsgDuplicateDeleted  msgKey = "MsgDuplicateDeleted"
+	MsgDuplicateKept     msgKey = "MsgDuplicateKept"
+	MsgDuplicateMerged   msgKey = "MsgDuplicateMerged"
+	MsgDuplicateRestored msgKey = "MsgDuplicateRestored"
+	MsgTrash             msgKey = "MsgTrash"
+	MsgTrashEmpty        msgKey = "MsgTrashEmpty"
+	MsgRetention         msgKey = "MsgRetention"
+	MsgRetentionSet      msgKey = "MsgRetentionSet"
+	MsgExported          msgKey = "MsgExported"
+	MsgExportDeleted     msgKey = "MsgExportDeleted"
+	MsgExportDeletedBy   msgKey = "MsgExportDeletedBy"
+	MsgImportPreview     msgKey = "MsgImportPreview"
+	MsgImportDuplicates  msgKey = "MsgImportDuplicates"
+	MsgImportTitle       msgKey = "MsgImportTitle"
+	MsgImported          msgKey = "MsgImported"
+	MsgImportCancelled   msgKey = "MsgImportCancelled"
+	MsgCloneName         msgKey = "MsgCloneName"
+	MsgListCloned        msgKey = "MsgListCloned"
+	MsgTemplateSaved     msgKey = "MsgTemplateSaved"
+	MsgTemplateUsed      msgKey = "MsgTemplateUsed"
+	MsgTemplateDeleted   msgKey = "MsgTemplateDeleted"
+	MsgTemplates         msgKey = "MsgTemplates"
+	MsgNoTemplates       msgKey = "MsgNoTemplates"
+	MsgSchedule          msgKey = "MsgSchedule"
+	MsgScheduleRestore   msgKey = "MsgScheduleRestore"
+	MsgScheduleTemplate  msgKey = "MsgScheduleTemplate"
+	MsgScheduleSilent    msgKey = "MsgScheduleSilent"
+	MsgNoSchedule        msgKey = "MsgNoSchedule"
+	MsgScheduleOff       msgKey = "MsgScheduleOff"
+	MsgListReset         msgKey = "MsgListReset"
+	MsgItemDue           msgKey = "MsgItemDue"
+	MsgDueSuffix         msgKey = "MsgDueSuffix"
+	MsgOverdue           msgKey = "MsgOverdue"
+	MsgOverdueItem       msgKey = "MsgOverdueItem"
+	MsgNoOverdue         msgKey = "MsgNoOverdue"
+	MsgEditItems         msgKey = "MsgEditItems"
+	MsgHelp              msgKey = "MsgHelp"
+	MsgWelcome           msgKey = "MsgWelcome"
+	MsgYourID            msgKey = "MsgYourID"
+	MsgNoLists           msgKey = "MsgNoLists"
+	MsgOpenApp           msgKey = "MsgOpenApp"
+	MsgInviteLine        msgKey = "MsgInviteLine"
+	MsgNotifyEnabled     msgKey = "MsgNotifyEnabled"
+	MsgNotifyDisabled    msgKey = "MsgNotifyDisabled"
+	MsgUnknownUser       msgKey = "MsgUnknownUser"
+	MsgRoleOwner         msgKey = "MsgRoleOwner"
+	MsgRoleEditor        msgKey = "MsgRoleEditor"
+	MsgRoleViewer        msgKey = "MsgRoleViewer"
+	MsgLang              msgKey = "MsgLang"
+	MsgLangSet           msgKey = "MsgLangSet"
+	MsgLangAuto          msgKey = "MsgLangAuto"
 )
 
+// Button labels
+const (
+	BtnConfirm        msgKey = "BtnConfirm"
+	BtnCancel         msgKey = "BtnCancel"
+	BtnDelete         msgKey = "BtnDelete"
+	BtnRestore        msgKey = "BtnRestore"
+	BtnKeepBoth       msgKey = "BtnKeepBoth"
+	BtnMerge          msgKey = "BtnMerge"
+	BtnRestoreDeleted msgKey = "BtnRestoreDeleted"
+	BtnImportNew      msgKey = "BtnImportNew"
+	BtnImportActive   msgKey = "BtnImportActive"
+	BtnImportUnique   msgKey = "BtnImportUnique"
+	BtnImportAll      msgKey = "BtnImportAll"
+	BtnRevokeInvite   msgKey = "BtnRevokeInvite"
+	BtnClearDone      msgKey = "BtnClearDone"
+	BtnOpenApp        msgKey = "BtnOpenApp"
+	BtnLeaveList      msgKey = "BtnLeaveList"
+)
+
+// roleTitle is the role as shown to users
+func roleTitle(ctx context.Context, role Role) string {
+	switch role {
+	case RoleOwner:
+		return tr(ctx, MsgRoleOwner)
+	case RoleEditor:
+		return tr(ctx, MsgRoleEditor)
+	default:
+		return tr(ctx, MsgRoleViewer)
+	}
+}
+
 // escapeMarkdown escapes special characters for Markdown parsing
 func escapeMarkdown(text string) string {
 	specialChars := []string{"-", "*", "_", "`", "[", "]", "(", ")", ".", "!", "#", "<", ">", "{", "}", "=", "+"}
@@ -64,9 +228,21 @@ func sendMessage(ctx context.Context, b *bot.Bot, chatID int64, text string) err
 	return err
 }
 
-// sendInlineKeyboard sends an inline keyboard with error logging
-func sendInlineKeyboard(ctx context.Context, b *bot.Bot, chatID int64, text string, kb *models.InlineKeyboardMarkup) error {
+// sendPlainMessage sends text without Markdown, for content such as links that must stay intact
+func sendPlainMessage(ctx context.Context, b *bot.Bot, chatID int64, text string) error {
 	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   text,
+	})
+	if err != nil {
+		log.Printf("Failed to send message to %d: %v", chatID, err)
+	}
+	return err
+}
+
+// sendInlineKeyboard sends an inline keyboard with error logging
+func sendInlineKeyboard(ctx context.Context, b *bot.Bot, chatID int64, text string, kb *models.InlineKeyboardMarkup) (*models.Message, error) {
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID:      chatID,
 		Text:        escapeMarkdown(text),
 		ReplyMarkup: kb,
@@ -75,6 +251,49 @@ func sendInlineKeyboard(ctx context.Context, b *bot.Bot, chatID int64, text stri
 	if err != nil {
 		log.Printf("Failed to send inline keyboard to %d: %v", chatID, err)
 	}
+	return msg, err
+}
+
+// editMessage replaces the text of a sent message and drops its inline keyboard
+func editMessage(ctx context.Context, b *bot.Bot, msg *models.Message, text string) error {
+	_, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:    msg.Chat.ID,
+		MessageID: msg.ID,
+		Text:      escapeMarkdown(text),
+		ParseMode: models.ParseModeMarkdown,
+	})
+	if err != nil {
+		log.Printf("Failed to edit message %d for %d: %v", msg.ID, msg.Chat.ID, err)
+	}
+	return err
+}
+
+// editInlineKeyboard updates a sent message and its inline keyboard in place,
+// only the keyboard is edited when the text stays the same
+func editInlineKeyboard(ctx context.Context, b *bot.Bot, msg *models.Message, text string, kb *models.InlineKeyboardMarkup) error {
+	var err error
+	if msg.Text == text {
+		_, err = b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+			ChatID:      msg.Chat.ID,
+			MessageID:   msg.ID,
+			ReplyMarkup: kb,
+		})
+	} else {
+		_, err = b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      msg.Chat.ID,
+			MessageID:   msg.ID,
+			Text:        escapeMarkdown(text),
+			ReplyMarkup: kb,
+			ParseMode:   models.ParseModeMarkdown,
+		})
+	}
+	// Telegram refuses edits that change nothing, the message is already up to date then
+	if err != nil && strings.Contains(err.Error(), "message is not modified") {
+		return nil
+	}
+	if err != nil {
+		log.Printf("Failed to edit message %d for %d: %v", msg.ID, msg.Chat.ID, err)
+	}
 	return err
 }
 
@@ -96,6 +315,14 @@ func answerCallback(ctx context.Context, b *bot.Bot, update *models.Update) erro
 	return err
 }
 
+// sendStoreError reports a failed store call, telling permission errors apart from the rest
+func sendStoreError(ctx context.Context, b *bot.Bot, chatID int64, err error, key msgKey) error {
+	if errors.Is(err, ErrForbidden) {
+		key = ErrNoPermission
+	}
+	return sendMessage(ctx, b, chatID, tr(ctx, key))
+}
+
 // getUserID extracts user ID from update
 func getUserID(update *models.Update) (int64, error) {
 	if update.Message != nil {
@@ -107,6 +334,23 @@ func getUserID(update *models.Update) (int64, error) {
 	return 0, fmt.Errorf("no user ID found in update")
 }
 
+// userDisplayName returns the user's name as Telegram shows it, falling back to the ID
+func userDisplayName(ctx context.Context, b *bot.Bot, userID int64) string {
+	chat, err := b.GetChat(ctx, &bot.GetChatParams{ChatID: userID})
+	if err != nil {
+		log.Printf("Failed to get chat %d: %v", userID, err)
+		return strconv.FormatInt(userID, 10)
+	}
+	name := strings.TrimSpace(chat.FirstName + " " + chat.LastName)
+	if name == "" && chat.Username != "" {
+		name = "@" + chat.Username
+	}
+	if name == "" {
+		return strconv.FormatInt(userID, 10)
+	}
+	return name
+}
+
 // parseInt64 parses a string to int64 with error handling
 func parseInt64(s string) (int64, error) {
 	return strconv.ParseInt(s, 10, 64)